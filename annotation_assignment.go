@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	annotationNotStarted = "not_started"
+	annotationInProgress = "in_progress"
+	annotationSubmitted  = "submitted"
+	annotationReviewed   = "reviewed"
+
+	maxAssigneeLength = 100
+)
+
+var annotationStatuses = []string{annotationNotStarted, annotationInProgress, annotationSubmitted, annotationReviewed}
+
+// annotationTransitions lists the statuses an annotation can move to from each status. Once started an annotation
+// does not become not started again, only submitted annotations are reviewed and reviewed ones are reopened in
+// progress for rework.
+var annotationTransitions = map[string][]string{
+	annotationNotStarted: {annotationInProgress, annotationSubmitted},
+	annotationInProgress: {annotationSubmitted},
+	annotationSubmitted:  {annotationInProgress, annotationReviewed},
+	annotationReviewed:   {annotationInProgress},
+}
+
+// annotationStatusOrDefault returns the status of an annotation, annotations without status are not started
+func annotationStatusOrDefault(status string) string {
+	if status == "" {
+		return annotationNotStarted
+	}
+	return status
+}
+
+// validateAnnotationAssignment checks the assignee and status to set and returns the assignment with a trimmed
+// assignee. The status can only move along annotationTransitions.
+func validateAnnotationAssignment(assignment AnnotationAssignment, status string) (AnnotationAssignment, []ValidationError) {
+	var errs []ValidationError
+	if assignment.Assignee != nil {
+		assignee := strings.TrimSpace(*assignment.Assignee)
+		assignment.Assignee = &assignee
+		if utf8.RuneCountInString(assignee) > maxAssigneeLength {
+			errs = append(errs, ValidationError{Field: "assignee", Message: fmt.Sprintf("assignee must not be longer than %d characters", maxAssigneeLength)})
+		}
+	}
+	switch {
+	case assignment.Status == "":
+	case !containsString(annotationStatuses, assignment.Status):
+		errs = append(errs, ValidationError{Field: "status", Message: fmt.Sprintf("status must be one of %v", annotationStatuses)})
+	case assignment.Status != annotationStatusOrDefault(status) && !containsString(annotationTransitions[annotationStatusOrDefault(status)], assignment.Status):
+		errs = append(errs, ValidationError{Field: "status", Message: fmt.Sprintf("a %s annotation can not become %s", annotationStatusOrDefault(status), assignment.Status)})
+	}
+	return assignment, errs
+}
+
+// annotationProgress counts the documents of the annotation with at least one code or marked as done
+func annotationProgress(annotation Annotation) AnnotationProgress {
+	progress := AnnotationProgress{
+		Name:            annotation.Name,
+		Dataset:         annotation.Dataset,
+		Assignee:        annotation.Assignee,
+		Status:          annotationStatusOrDefault(annotation.Status),
+		StatusChangedAt: annotation.StatusChangedAt,
+		LastUpdated:     annotation.LastUpdated,
+		NumDocs:         len(annotation.Docs),
+	}
+	for _, doc := range annotation.Docs {
+		coded := doc.BeginIndex != nil && doc.EndIndex != nil && hasCodeInRange(annotation.Codes, *doc.BeginIndex, *doc.EndIndex)
+		done := containsString(annotation.DoneDocs, doc.Name)
+		if coded {
+			progress.NumDocsCoded++
+		}
+		if done {
+			progress.NumDocsDone++
+		}
+		if coded || done {
+			progress.NumDocsComplete++
+		}
+	}
+	if progress.NumDocs > 0 {
+		progress.Progress = float64(progress.NumDocsComplete) / float64(progress.NumDocs)
+	}
+	return progress
+}
+
+func hasCodeInRange(codes []Code, begin int, end int) bool {
+	for _, code := range codes {
+		for _, token := range code.Tokens {
+			if token != nil && *token >= begin && *token < end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// workQueue returns the annotations an annotator still has to work on, the ones in progress first and then the
+// least recently updated
+func workQueue(annotations []Annotation) []AnnotationProgress {
+	queue := []AnnotationProgress{}
+	for _, annotation := range annotations {
+		progress := annotationProgress(annotation)
+		if progress.Status == annotationNotStarted || progress.Status == annotationInProgress {
+			queue = append(queue, progress)
+		}
+	}
+	sort.SliceStable(queue, func(i, j int) bool {
+		if queue[i].Status != queue[j].Status {
+			return queue[i].Status == annotationInProgress
+		}
+		return queue[i].LastUpdated.Before(queue[j].LastUpdated)
+	})
+	return queue
+}
+
+// keepAnnotationAssignment replaces the assignment of a saved annotation with the stored one, it is only changed
+// through the assignment and done routes
+func keepAnnotationAssignment(annotation *Annotation, stored Annotation) {
+	annotation.Assignee = stored.Assignee
+	annotation.Status = stored.Status
+	annotation.StatusChangedAt = stored.StatusChangedAt
+	annotation.DoneDocs = stored.DoneDocs
+}
+
+// startAnnotation moves an assigned annotation that was not started yet in progress once it is saved with codes
+func startAnnotation(annotation *Annotation, stored Annotation, now time.Time) {
+	if stored.Assignee == "" || annotationStatusOrDefault(stored.Status) != annotationNotStarted || len(annotation.Codes) == 0 {
+		return
+	}
+	annotation.Status = annotationInProgress
+	annotation.StatusChangedAt = &now
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// validateCloneTores checks that the categories to keep exist in the scheme of the annotation
+func validateCloneTores(tores []string, categories []ToreCategory) []ValidationError {
+	if len(categories) == 0 {
+		return nil
+	}
+	var names []string
+	for _, category := range categories {
+		names = append(names, category.Name)
+	}
+	var errs []ValidationError
+	for i, tore := range tores {
+		if !containsString(names, tore) {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("tores[%d]", i), Message: fmt.Sprintf("%q is not a TORE category", tore)})
+		}
+	}
+	return errs
+}
+
+// cloneAnnotation returns an unassigned copy of the annotation under the name of the request with the requested codes.
+// Relationships of removed codes are removed as well and the code counters of the tokens are recomputed.
+func cloneAnnotation(source Annotation, request AnnotationCloneRequest, now time.Time) Annotation {
+	clone := source
+	clone.Name = request.Name
+	clone.UploadedAt = now
+	clone.LastUpdated = now
+	clone.ClonedFrom = source.Name
+	clone.Assignee, clone.Status, clone.StatusChangedAt, clone.DoneDocs = "", "", nil, nil
+	clone.Tokens = append([]Token{}, source.Tokens...)
+	clone.Codes = []Code{}
+	for _, code := range source.Codes {
+		if request.StripCodes || (len(request.Tores) > 0 && !containsString(request.Tores, code.Tore)) {
+			continue
+		}
+		clone.Codes = append(clone.Codes, code)
+	}
+	clone.TORERelationships = append([]TORERelationship{}, source.TORERelationships...)
+	clone, _ = repairAnnotation(clone)
+	return clone
+}
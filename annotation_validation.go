@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+)
+
+// validateAnnotationTores checks that every code uses an active TORE category. Categories that were deprecated
+// while already in use by the stored version of the annotation remain allowed, so existing work can still be saved.
+func validateAnnotationTores(annotation Annotation, categories []ToreCategory, stored Annotation) []ValidationError {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, category := range categories {
+		if category.State == toreCategoryActive {
+			allowed[category.Name] = true
+		}
+	}
+	for _, code := range stored.Codes {
+		allowed[code.Tore] = true
+	}
+
+	var errs []ValidationError
+	for i, code := range annotation.Codes {
+		if code.Tore == "" || allowed[code.Tore] {
+			continue
+		}
+		errs = append(errs, ValidationError{
+			Field:   fmt.Sprintf("codes[%d].tore", i),
+			Message: fmt.Sprintf("%q is not an active TORE category", code.Tore),
+		})
+	}
+	return errs
+}
+
+// validateAnnotationRelationships checks every relationship against its relationship type: the type has to exist,
+// the code at TOREEntity has to have an allowed source category and every target token has to be coded with an allowed
+// target category. Dangling references are left to the structural validation.
+func validateAnnotationRelationships(annotation Annotation, types []RelationshipType) []ValidationError {
+	if len(types) == 0 {
+		return nil
+	}
+
+	typesByName := make(map[string]RelationshipType)
+	for _, relationshipType := range types {
+		typesByName[relationshipType.Name] = relationshipType
+	}
+	codesByIndex := make(map[int]Code)
+	toresByToken := make(map[int][]string)
+	for _, code := range annotation.Codes {
+		if code.Index != nil {
+			codesByIndex[*code.Index] = code
+		}
+		if code.Tore == "" {
+			continue
+		}
+		for _, token := range code.Tokens {
+			if token != nil {
+				toresByToken[*token] = append(toresByToken[*token], code.Tore)
+			}
+		}
+	}
+
+	var errs []ValidationError
+	for i, relationship := range annotation.TORERelationships {
+		relationshipType, ok := typesByName[relationship.RelationshipName]
+		if !ok {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("tore_relationships[%d].relationship_name", i),
+				Message: fmt.Sprintf("%q is not a relationship type", relationship.RelationshipName),
+			})
+			continue
+		}
+
+		if relationship.TOREEntity != nil && len(relationshipType.SourceTores) > 0 {
+			if source, ok := codesByIndex[*relationship.TOREEntity]; ok && !containsString(relationshipType.SourceTores, source.Tore) {
+				errs = append(errs, ValidationError{
+					Field:   fmt.Sprintf("tore_relationships[%d].TOREEntity", i),
+					Message: fmt.Sprintf("%q relationships can not start at a %q code, allowed are %v", relationship.RelationshipName, source.Tore, relationshipType.SourceTores),
+				})
+			}
+		}
+
+		if len(relationshipType.TargetTores) == 0 {
+			continue
+		}
+		for _, token := range relationship.TargetTokens {
+			if token == nil || hasAllowedTore(toresByToken[*token], relationshipType.TargetTores) {
+				continue
+			}
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("tore_relationships[%d].target_tokens", i),
+				Message: fmt.Sprintf("%q relationships can not target token %d coded as %v, allowed are %v", relationship.RelationshipName, *token, toresByToken[*token], relationshipType.TargetTores),
+			})
+		}
+	}
+	return errs
+}
+
+func hasAllowedTore(tores []string, allowed []string) bool {
+	for _, tore := range tores {
+		if containsString(allowed, tore) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAnnotationStructure checks the references within an annotation: token, document and sentence indices have
+// to be in range, code and relationship indices unique, relationship memberships and TOREEntity have to point at
+// existing relationships and codes, and the code counters of every token have to match its codes.
+func validateAnnotationStructure(annotation Annotation) []ValidationError {
+	var errs []ValidationError
+	numTokens := len(annotation.Tokens)
+	for i, token := range annotation.Tokens {
+		if token.Index == nil || *token.Index != i {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("tokens[%d].index", i), Message: fmt.Sprintf("index must be %d", i)})
+		}
+	}
+	errs = append(errs, validateTokenRanges("docs", annotation.Docs, numTokens)...)
+	errs = append(errs, validateTokenRanges("sentences", annotation.Sentences, numTokens)...)
+
+	relationshipIndices := make(map[int]bool)
+	for i, relationship := range annotation.TORERelationships {
+		field := fmt.Sprintf("tore_relationships[%d]", i)
+		switch {
+		case relationship.Index == nil:
+			errs = append(errs, ValidationError{Field: field + ".index", Message: "index must be set"})
+		case relationshipIndices[*relationship.Index]:
+			errs = append(errs, ValidationError{Field: field + ".index", Message: fmt.Sprintf("index %d is used by another relationship", *relationship.Index)})
+		default:
+			relationshipIndices[*relationship.Index] = true
+		}
+		errs = append(errs, validateTokenReferences(field+".target_tokens", relationship.TargetTokens, numTokens)...)
+	}
+
+	codeIndices := make(map[int]bool)
+	numNameCodes := make([]int, numTokens)
+	numToreCodes := make([]int, numTokens)
+	for i, code := range annotation.Codes {
+		field := fmt.Sprintf("codes[%d]", i)
+		switch {
+		case code.Index == nil:
+			errs = append(errs, ValidationError{Field: field + ".index", Message: "index must be set"})
+		case codeIndices[*code.Index]:
+			errs = append(errs, ValidationError{Field: field + ".index", Message: fmt.Sprintf("index %d is used by another code", *code.Index)})
+		default:
+			codeIndices[*code.Index] = true
+		}
+		if len(code.Tokens) == 0 {
+			errs = append(errs, ValidationError{Field: field + ".tokens", Message: "a code must cover at least one token"})
+		}
+		errs = append(errs, validateTokenReferences(field+".tokens", code.Tokens, numTokens)...)
+		for _, token := range uniqueTokenIndices(code.Tokens, numTokens) {
+			if code.Name != "" {
+				numNameCodes[token]++
+			}
+			if code.Tore != "" {
+				numToreCodes[token]++
+			}
+		}
+		for j, membership := range code.RelationshipMemberships {
+			if membership == nil || !relationshipIndices[*membership] {
+				errs = append(errs, ValidationError{Field: fmt.Sprintf("%s.relationship_memberships[%d]", field, j), Message: "relationship does not exist"})
+			}
+		}
+	}
+
+	for i, relationship := range annotation.TORERelationships {
+		if relationship.TOREEntity == nil || !codeIndices[*relationship.TOREEntity] {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("tore_relationships[%d].TOREEntity", i), Message: "code does not exist"})
+		}
+	}
+	for i, token := range annotation.Tokens {
+		if token.NumNameCodes != numNameCodes[i] {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("tokens[%d].num_name_codes", i), Message: fmt.Sprintf("token is covered by %d named codes", numNameCodes[i])})
+		}
+		if token.NumToreCodes != numToreCodes[i] {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("tokens[%d].num_tore_codes", i), Message: fmt.Sprintf("token is covered by %d codes with a TORE category", numToreCodes[i])})
+		}
+	}
+	return errs
+}
+
+// validateTokenRanges checks that documents or sentences cover a valid range of tokens
+func validateTokenRanges(field string, ranges []DocWrapper, numTokens int) []ValidationError {
+	var errs []ValidationError
+	for i, r := range ranges {
+		if r.BeginIndex == nil || r.EndIndex == nil || *r.BeginIndex < 0 || *r.BeginIndex > *r.EndIndex || *r.EndIndex > numTokens {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("%s[%d]", field, i), Message: fmt.Sprintf("begin_index and end_index must be a range within the %d tokens", numTokens)})
+		}
+	}
+	return errs
+}
+
+// validateTokenReferences checks that token references are set, in range and given only once
+func validateTokenReferences(field string, tokens []*int, numTokens int) []ValidationError {
+	var errs []ValidationError
+	seen := make(map[int]bool)
+	for i, token := range tokens {
+		switch {
+		case token == nil || *token < 0 || *token >= numTokens:
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("%s[%d]", field, i), Message: fmt.Sprintf("token must be an index below %d", numTokens)})
+		case seen[*token]:
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("%s[%d]", field, i), Message: fmt.Sprintf("token %d is given more than once", *token)})
+		default:
+			seen[*token] = true
+		}
+	}
+	return errs
+}
+
+// uniqueTokenIndices returns the valid token references sorted, each only once
+func uniqueTokenIndices(tokens []*int, numTokens int) []int {
+	var indices []int
+	seen := make(map[int]bool)
+	for _, index := range validTokenIndices(tokens, numTokens) {
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	return indices
+}
+
+// repairAnnotation fixes the references the structural validation reports without guessing the intent of an
+// annotator: token indices are renumbered, invalid token references dropped, codes without tokens and relationships of removed
+// codes are removed, missing or duplicate indices are reassigned, dangling memberships are dropped and the code
+// counters are recomputed. Document and sentence ranges are not repaired. The returned repairs list every change using the fields of the posted annotation.
+func repairAnnotation(annotation Annotation) (Annotation, []ValidationError) {
+	var repairs []ValidationError
+	numTokens := len(annotation.Tokens)
+	tokens := make([]Token, numTokens)
+	for i, token := range annotation.Tokens {
+		if token.Index == nil || *token.Index != i {
+			repairs = append(repairs, ValidationError{Field: fmt.Sprintf("tokens[%d].index", i), Message: fmt.Sprintf("set index to %d", i)})
+		}
+		index := i
+		token.Index = &index
+		tokens[i] = token
+	}
+
+	nextCodeIndex := 0
+	for _, code := range annotation.Codes {
+		if code.Index != nil && *code.Index >= nextCodeIndex {
+			nextCodeIndex = *code.Index + 1
+		}
+	}
+	codes := []Code{}
+	positions := []int{}
+	codeIndices := make(map[int]bool)
+	for i, code := range annotation.Codes {
+		field := fmt.Sprintf("codes[%d]", i)
+		if errs := validateTokenReferences(field+".tokens", code.Tokens, numTokens); len(errs) > 0 {
+			repairs = append(repairs, ValidationError{Field: field + ".tokens", Message: fmt.Sprintf("removed %d invalid token references", len(errs))})
+		}
+		code.Tokens = intPointers(uniqueTokenIndices(code.Tokens, numTokens))
+		if len(code.Tokens) == 0 {
+			repairs = append(repairs, ValidationError{Field: field, Message: "removed code without tokens"})
+			continue
+		}
+		if code.Index == nil || codeIndices[*code.Index] {
+			index := nextCodeIndex
+			nextCodeIndex++
+			code.Index = &index
+			repairs = append(repairs, ValidationError{Field: field + ".index", Message: fmt.Sprintf("set index to %d", index)})
+		}
+		codeIndices[*code.Index] = true
+		codes = append(codes, code)
+		positions = append(positions, i)
+	}
+
+	nextRelationshipIndex := 0
+	for _, relationship := range annotation.TORERelationships {
+		if relationship.Index != nil && *relationship.Index >= nextRelationshipIndex {
+			nextRelationshipIndex = *relationship.Index + 1
+		}
+	}
+	relationships := []TORERelationship{}
+	relationshipIndices := make(map[int]bool)
+	for i, relationship := range annotation.TORERelationships {
+		field := fmt.Sprintf("tore_relationships[%d]", i)
+		if relationship.TOREEntity == nil || !codeIndices[*relationship.TOREEntity] {
+			repairs = append(repairs, ValidationError{Field: field, Message: "removed relationship of a code that does not exist"})
+			continue
+		}
+		if errs := validateTokenReferences(field+".target_tokens", relationship.TargetTokens, numTokens); len(errs) > 0 {
+			repairs = append(repairs, ValidationError{Field: field + ".target_tokens", Message: fmt.Sprintf("removed %d invalid token references", len(errs))})
+			relationship.TargetTokens = intPointers(uniqueTokenIndices(relationship.TargetTokens, numTokens))
+		}
+		if relationship.Index == nil || relationshipIndices[*relationship.Index] {
+			index := nextRelationshipIndex
+			nextRelationshipIndex++
+			relationship.Index = &index
+			repairs = append(repairs, ValidationError{Field: field + ".index", Message: fmt.Sprintf("set index to %d", index)})
+		}
+		relationshipIndices[*relationship.Index] = true
+		relationships = append(relationships, relationship)
+	}
+
+	for i, code := range codes {
+		memberships := []*int{}
+		for _, membership := range code.RelationshipMemberships {
+			if membership != nil && relationshipIndices[*membership] {
+				memberships = append(memberships, membership)
+			}
+		}
+		if len(memberships) != len(code.RelationshipMemberships) {
+			repairs = append(repairs, ValidationError{Field: fmt.Sprintf("codes[%d].relationship_memberships", positions[i]), Message: fmt.Sprintf("removed %d memberships of relationships that do not exist", len(code.RelationshipMemberships)-len(memberships))})
+		}
+		codes[i].RelationshipMemberships = memberships
+	}
+
+	numNameCodes := make([]int, numTokens)
+	numToreCodes := make([]int, numTokens)
+	for _, code := range codes {
+		for _, token := range code.Tokens {
+			if code.Name != "" {
+				numNameCodes[*token]++
+			}
+			if code.Tore != "" {
+				numToreCodes[*token]++
+			}
+		}
+	}
+	for i := range tokens {
+		if tokens[i].NumNameCodes != numNameCodes[i] || tokens[i].NumToreCodes != numToreCodes[i] {
+			repairs = append(repairs, ValidationError{Field: fmt.Sprintf("tokens[%d]", i), Message: fmt.Sprintf("set num_name_codes to %d and num_tore_codes to %d", numNameCodes[i], numToreCodes[i])})
+			tokens[i].NumNameCodes, tokens[i].NumToreCodes = numNameCodes[i], numToreCodes[i]
+		}
+	}
+
+	annotation.Tokens = tokens
+	annotation.Codes = codes
+	annotation.TORERelationships = relationships
+	return annotation, repairs
+}
+
+func intPointers(values []int) []*int {
+	pointers := make([]*int, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	return pointers
+}
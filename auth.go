@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	roleAdmin          = "admin"
+	roleResearcher     = "researcher"
+	roleAnnotator      = "annotator"
+	roleCrawlerService = "crawler-service"
+
+	authorizationKey = "Authorization"
+	apiKeyKey        = "X-API-Key"
+)
+
+var roles = []string{roleAdmin, roleResearcher, roleAnnotator, roleCrawlerService}
+
+// role groups allowed on the routes, admins are allowed everywhere. Readers also save annotations, annotators only
+// the ones assigned to them, which is checked by checkAnnotationModification.
+var (
+	readers        = []string{roleAdmin, roleResearcher, roleAnnotator}
+	researchers    = []string{roleAdmin, roleResearcher}
+	admins         = []string{roleAdmin}
+	crawlers       = []string{roleAdmin, roleCrawlerService}
+	crawlerReaders = []string{roleAdmin, roleResearcher, roleCrawlerService}
+	datasetWriters = []string{roleAdmin, roleResearcher, roleCrawlerService}
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Name string
+	Role string
+}
+
+// authenticator verifies the credentials of requests, either JWTs signed with the configured key or static API keys
+type authenticator struct {
+	disabled     bool
+	jwtSecret    []byte
+	jwtPublicKey *rsa.PublicKey
+	// apiKeys are the principals by the hex encoded SHA-256 hash of their key
+	apiKeys map[string]Principal
+}
+
+var authn authenticator
+
+type principalKey struct{}
+
+// loadAuthenticator reads the authentication configuration:
+// AUTH_JWT_SECRET is the key of HS256 signed tokens, AUTH_JWT_PUBLIC_KEY_FILE the PEM encoded public key of RS256
+// signed tokens, AUTH_API_KEYS a comma separated list of name:role:key and AUTH_DISABLED=true disables authentication.
+func loadAuthenticator() (authenticator, error) {
+	if os.Getenv("AUTH_DISABLED") == "true" {
+		return authenticator{disabled: true}, nil
+	}
+
+	a := authenticator{apiKeys: make(map[string]Principal)}
+	if secret := os.Getenv("AUTH_JWT_SECRET"); secret != "" {
+		a.jwtSecret = []byte(secret)
+	}
+	if file := os.Getenv("AUTH_JWT_PUBLIC_KEY_FILE"); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return a, fmt.Errorf("reading AUTH_JWT_PUBLIC_KEY_FILE: %s", err)
+		}
+		if a.jwtPublicKey, err = parseRSAPublicKey(data); err != nil {
+			return a, fmt.Errorf("parsing AUTH_JWT_PUBLIC_KEY_FILE: %s", err)
+		}
+	}
+	for _, entry := range strings.Split(os.Getenv("AUTH_API_KEYS"), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" || !containsString(roles, parts[1]) {
+			return a, fmt.Errorf("AUTH_API_KEYS entries must be name:role:key with a role of %v", roles)
+		}
+		a.apiKeys[hashAPIKey(parts[2])] = Principal{Name: parts[0], Role: parts[1]}
+	}
+
+	if a.jwtSecret == nil && a.jwtPublicKey == nil && len(a.apiKeys) == 0 {
+		return a, errors.New("no authentication configured, set AUTH_JWT_SECRET, AUTH_JWT_PUBLIC_KEY_FILE or AUTH_API_KEYS, or AUTH_DISABLED=true")
+	}
+	return a, nil
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, errors.New("not an RSA public key")
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// authenticate returns the caller of the request from its bearer token or API key
+func (a authenticator) authenticate(r *http.Request, now time.Time) (Principal, error) {
+	if a.disabled {
+		return Principal{Role: roleAdmin}, nil
+	}
+	key := r.Header.Get(apiKeyKey)
+	if bearer := r.Header.Get(authorizationKey); key == "" && strings.HasPrefix(bearer, "Bearer ") {
+		key = strings.TrimSpace(strings.TrimPrefix(bearer, "Bearer "))
+		if strings.Count(key, ".") == 2 {
+			return a.verifyJWT(key, now)
+		}
+	}
+	if key == "" {
+		return Principal{}, errors.New("missing credentials")
+	}
+	if principal, ok := a.apiKeys[hashAPIKey(key)]; ok {
+		return principal, nil
+	}
+	return Principal{}, errors.New("invalid API key")
+}
+
+// verifyJWT checks the signature and validity of a token and returns the principal of its sub and role claims
+func (a authenticator) verifyJWT(token string, now time.Time) (Principal, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return Principal{}, errors.New("invalid token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, errors.New("invalid token signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch {
+	case header.Alg == "HS256" && a.jwtSecret != nil:
+		mac := hmac.New(sha256.New, a.jwtSecret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return Principal{}, errors.New("invalid token signature")
+		}
+	case header.Alg == "RS256" && a.jwtPublicKey != nil:
+		hash := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(a.jwtPublicKey, crypto.SHA256, hash[:], signature) != nil {
+			return Principal{}, errors.New("invalid token signature")
+		}
+	default:
+		return Principal{}, fmt.Errorf("token algorithm %q is not accepted", header.Alg)
+	}
+
+	var claims struct {
+		Subject   string   `json:"sub"`
+		Role      string   `json:"role"`
+		ExpiresAt *float64 `json:"exp"`
+		NotBefore *float64 `json:"nbf"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return Principal{}, errors.New("invalid token claims")
+	}
+	if claims.ExpiresAt == nil || now.Unix() >= int64(*claims.ExpiresAt) {
+		return Principal{}, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Unix() < int64(*claims.NotBefore) {
+		return Principal{}, errors.New("token not valid yet")
+	}
+	if claims.Subject == "" || !containsString(roles, claims.Role) {
+		return Principal{}, fmt.Errorf("token needs a sub and a role of %v", roles)
+	}
+	return Principal{Name: claims.Subject, Role: claims.Role}, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// allow authenticates the request and only calls the handler if the caller has one of the roles and access to the
+// project of the request
+func allow(allowed []string, handler http.HandlerFunc) http.HandlerFunc {
+	return allowUnscoped(allowed, func(w http.ResponseWriter, r *http.Request) {
+		project, status, err := resolveProject(r, requestPrincipal(r))
+		if err != nil {
+			fmt.Printf("ERROR resolving project of %s %s: %s\n", r.Method, r.URL.Path, err)
+			w.Header().Set(contentTypeKey, contentTypeValJSON)
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: err.Error(), Status: false})
+			return
+		}
+		handler(w, withProject(r, project))
+	})
+}
+
+// allowUnscoped authenticates the request and only calls the handler if the caller has one of the roles, it is used
+// for the routes that are not scoped to a project
+func allowUnscoped(allowed []string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authn.authenticate(r, time.Now())
+		if err != nil {
+			fmt.Printf("ERROR authenticating %s %s: %s\n", r.Method, r.URL.Path, err)
+			w.Header().Set(contentTypeKey, contentTypeValJSON)
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Authentication required", Status: false})
+			return
+		}
+		if !containsString(allowed, principal.Role) {
+			writeForbidden(w, fmt.Sprintf("Role %s is not allowed to %s %s", principal.Role, r.Method, r.URL.Path))
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	}
+}
+
+// requestPrincipal returns the caller of a request passed by allow
+func requestPrincipal(r *http.Request) Principal {
+	principal, _ := r.Context().Value(principalKey{}).(Principal)
+	return principal
+}
+
+// checkAnnotationModification checks that annotators only modify the annotations assigned to them and only until
+// they are reviewed, the stored annotation is empty for a new one
+func checkAnnotationModification(r *http.Request, name string, stored Annotation) error {
+	principal := requestPrincipal(r)
+	if principal.Role != roleAnnotator {
+		return nil
+	}
+	if stored.Assignee == "" || stored.Assignee != principal.Name {
+		return fmt.Errorf("Annotation %s is not assigned to you", name)
+	}
+	if stored.Status == annotationReviewed {
+		return fmt.Errorf("Annotation %s is already reviewed", name)
+	}
+	return nil
+}
+
+func writeForbidden(w http.ResponseWriter, message string) {
+	fmt.Printf("ERROR forbidden: %s\n", message)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: message, Status: false})
+}
+
+// allowedOrigins returns the origins allowed by CORS, configured as comma separated list with CORS_ALLOWED_ORIGINS.
+// No cross-origin requests are allowed if it is not set.
+func allowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// isAllowedOrigin returns the CORS origin check of the allowed origins, * allows every origin. It is used instead of
+// handlers.AllowedOrigins, which allows every origin for an empty list.
+func isAllowedOrigin(origins []string) func(string) bool {
+	return func(origin string) bool {
+		return containsString(origins, origin) || containsString(origins, "*")
+	}
+}
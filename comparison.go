@@ -0,0 +1,257 @@
+package main
+
+import (
+	"sort"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// compareResults compares the parameters, metrics, codes and document topics of the results
+func compareResults(results []Result) ResultComparison {
+	comparison := ResultComparison{
+		Results: make([]ResultSummary, len(results)),
+		Params:  compareParams(results),
+		Metrics: compareMetrics(results),
+	}
+	for i, result := range results {
+		comparison.Results[i] = ResultSummary{
+			StartedAt:   result.StartedAt,
+			Method:      result.Method,
+			Name:        result.Name,
+			DatasetName: result.DatasetName,
+			Status:      result.Status,
+		}
+	}
+
+	concepts := make([]map[string]bool, len(results))
+	for i, result := range results {
+		concepts[i] = codeConcepts(result.Codes)
+	}
+	comparison.Codes = compareConcepts(concepts)
+
+	for i := range results {
+		for j := i + 1; j < len(results); j++ {
+			comparison.Pairs = append(comparison.Pairs, ResultPair{
+				First:          i,
+				Second:         j,
+				Jaccard:        jaccard(concepts[i], concepts[j]),
+				TopicAlignment: alignTopics(results[i].DocTopic, results[j].DocTopic),
+			})
+		}
+	}
+	return comparison
+}
+
+// compareParams returns the parameters that are not equal in all results, nil marks a missing parameter
+func compareParams(results []Result) []ParamDifference {
+	names := make(map[string]bool)
+	for _, result := range results {
+		for name := range result.Params {
+			names[name] = true
+		}
+	}
+
+	differences := []ParamDifference{}
+	for _, name := range sortedKeys(names) {
+		difference := ParamDifference{Name: name, Values: make([]*string, len(results))}
+		differs := false
+		for i, result := range results {
+			if value, ok := result.Params[name]; ok {
+				difference.Values[i] = &value
+			}
+			if i > 0 && !equalOptionalStrings(difference.Values[0], difference.Values[i]) {
+				differs = true
+			}
+		}
+		if differs {
+			differences = append(differences, difference)
+		}
+	}
+	return differences
+}
+
+func equalOptionalStrings(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// compareMetrics returns the numeric metrics of the results with the spread between the lowest and highest value,
+// nested metrics like the evaluation are named by their dotted path
+func compareMetrics(results []Result) []MetricDifference {
+	metrics := make([]map[string]interface{}, len(results))
+	names := make(map[string]bool)
+	for i, result := range results {
+		metrics[i] = flattenMetrics(result.Metrics)
+		for name, value := range metrics[i] {
+			if _, ok := toFloat(value); ok {
+				names[name] = true
+			}
+		}
+	}
+
+	differences := []MetricDifference{}
+	for _, name := range sortedKeys(names) {
+		difference := MetricDifference{Name: name, Values: make([]*float64, len(results))}
+		var min, max *float64
+		for i := range results {
+			value, ok := toFloat(metrics[i][name])
+			if !ok {
+				continue
+			}
+			difference.Values[i] = &value
+			if min == nil || value < *min {
+				min = &value
+			}
+			if max == nil || value > *max {
+				max = &value
+			}
+		}
+		difference.Delta = *max - *min
+		differences = append(differences, difference)
+	}
+	return differences
+}
+
+// flattenMetrics returns the metrics with the values of nested documents keyed by their dotted path
+func flattenMetrics(metrics map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for name, value := range metrics {
+		nested, ok := value.(map[string]interface{})
+		if document, isDocument := value.(bson.M); isDocument {
+			nested, ok = document, true
+		}
+		if !ok {
+			flat[name] = value
+			continue
+		}
+		for path, nestedValue := range flattenMetrics(nested) {
+			flat[name+"."+path] = nestedValue
+		}
+	}
+	return flat
+}
+
+// codeConcepts returns the normalized names of the codes
+func codeConcepts(codes []Code) map[string]bool {
+	concepts := make(map[string]bool)
+	for _, code := range codes {
+		if name := normalizeTerm(code.Name); name != "" {
+			concepts[name] = true
+		}
+	}
+	return concepts
+}
+
+// compareConcepts returns the concepts detected in all results and the ones only detected in a single result
+func compareConcepts(concepts []map[string]bool) CodeComparison {
+	counts := make(map[string]int)
+	for _, set := range concepts {
+		for concept := range set {
+			counts[concept]++
+		}
+	}
+
+	comparison := CodeComparison{Shared: []string{}, Unique: make([][]string, len(concepts))}
+	for concept, count := range counts {
+		if count == len(concepts) {
+			comparison.Shared = append(comparison.Shared, concept)
+		}
+	}
+	sort.Strings(comparison.Shared)
+	for i, set := range concepts {
+		comparison.Unique[i] = []string{}
+		for concept := range set {
+			if counts[concept] == 1 {
+				comparison.Unique[i] = append(comparison.Unique[i], concept)
+			}
+		}
+		sort.Strings(comparison.Unique[i])
+	}
+	return comparison
+}
+
+func jaccard(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for concept := range a {
+		if b[concept] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// alignTopics matches every topic of the first result with the topic of the second result sharing the most
+// documents, nil is returned if the results have no documents with a topic in common
+func alignTopics(first map[string]interface{}, second map[string]interface{}) *TopicAlignment {
+	var documents []string
+	for document := range first {
+		if _, ok := second[document]; ok {
+			documents = append(documents, document)
+		}
+	}
+	sort.Strings(documents)
+
+	var firstTopics, secondTopics []string
+	for _, document := range documents {
+		firstTopic, ok := documentTopic(first[document])
+		if !ok {
+			continue
+		}
+		secondTopic, ok := documentTopic(second[document])
+		if !ok {
+			continue
+		}
+		firstTopics = append(firstTopics, firstTopic)
+		secondTopics = append(secondTopics, secondTopic)
+	}
+	if len(firstTopics) == 0 {
+		return nil
+	}
+
+	secondSizes := make(map[string]int)
+	for _, topic := range secondTopics {
+		secondSizes[topic]++
+	}
+	alignment := &TopicAlignment{
+		Documents: len(firstTopics),
+		NMI:       normalizedMutualInformation(firstTopics, secondTopics),
+		Matches:   []TopicMatch{},
+	}
+	table := contingency(firstTopics, secondTopics)
+	topics := make(map[string]bool)
+	for _, topic := range firstTopics {
+		topics[topic] = true
+	}
+	for _, topic := range sortedKeys(topics) {
+		counts := table[topic]
+		size, best, bestCount := 0, "", 0
+		for match, count := range counts {
+			size += count
+			// ties go to the smaller topic to keep the alignment stable
+			if count > bestCount || (count == bestCount && match < best) {
+				best, bestCount = match, count
+			}
+		}
+		alignment.Matches = append(alignment.Matches, TopicMatch{
+			Topic:   topic,
+			Match:   best,
+			Overlap: float64(bestCount) / float64(size+secondSizes[best]-bestCount),
+		})
+	}
+	return alignment
+}
+
+// sortedKeys returns the keys of the set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
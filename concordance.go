@@ -0,0 +1,148 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	defaultConcordanceWindow = 5
+	maxConcordanceWindow     = 50
+)
+
+// concordanceQuery selects codes by name, TORE category and/or relationship type, empty fields match everything
+type concordanceQuery struct {
+	Name         string
+	Tore         string
+	Relationship string
+	Window       int
+}
+
+// findConcordanceHits returns every code of the annotation that matches the query together with its context tokens
+func findConcordanceHits(annotation Annotation, query concordanceQuery) []ConcordanceHit {
+	relationshipsByCode := make(map[int][]string)
+	for _, relationship := range annotation.TORERelationships {
+		if relationship.TOREEntity == nil {
+			continue
+		}
+		codeIndex := *relationship.TOREEntity
+		relationshipsByCode[codeIndex] = append(relationshipsByCode[codeIndex], relationship.RelationshipName)
+	}
+
+	hits := []ConcordanceHit{}
+	for _, code := range annotation.Codes {
+		var relationshipNames []string
+		if code.Index != nil {
+			relationshipNames = relationshipsByCode[*code.Index]
+		}
+		if !matchesConcordanceQuery(code, relationshipNames, query) {
+			continue
+		}
+
+		tokenIndices := validTokenIndices(code.Tokens, len(annotation.Tokens))
+		if len(tokenIndices) == 0 {
+			continue
+		}
+		first := tokenIndices[0]
+		last := tokenIndices[len(tokenIndices)-1]
+		document, begin, end := documentBounds(annotation.Docs, first, len(annotation.Tokens))
+		// the context of codes spanning a document boundary ends with the document of their first token
+		contextEnd := last + 1
+		if contextEnd > end {
+			contextEnd = end
+		}
+
+		left := first - query.Window
+		if left < begin {
+			left = begin
+		}
+		right := contextEnd + query.Window
+		if right > end {
+			right = end
+		}
+		if left > first || contextEnd > right {
+			continue
+		}
+
+		var matchNames []string
+		var lemmas []string
+		for _, index := range tokenIndices {
+			matchNames = append(matchNames, annotation.Tokens[index].Name)
+			lemmas = append(lemmas, annotation.Tokens[index].Lemma)
+		}
+
+		hits = append(hits, ConcordanceHit{
+			AnnotationName:    annotation.Name,
+			Dataset:           annotation.Dataset,
+			Document:          document,
+			CodeIndex:         code.Index,
+			CodeName:          code.Name,
+			Tore:              code.Tore,
+			RelationshipNames: relationshipNames,
+			TokenIndices:      tokenIndices,
+			LeftContext:       joinTokenNames(annotation.Tokens[left:first]),
+			Match:             strings.Join(matchNames, " "),
+			RightContext:      joinTokenNames(annotation.Tokens[contextEnd:right]),
+			Lemmas:            lemmas,
+		})
+	}
+	return hits
+}
+
+func matchesConcordanceQuery(code Code, relationshipNames []string, query concordanceQuery) bool {
+	if query.Name != "" && !strings.EqualFold(code.Name, query.Name) {
+		return false
+	}
+	if query.Tore != "" && !strings.EqualFold(code.Tore, query.Tore) {
+		return false
+	}
+	if query.Relationship != "" {
+		for _, name := range relationshipNames {
+			if strings.EqualFold(name, query.Relationship) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// validTokenIndices returns the sorted token indices that point into the token list
+func validTokenIndices(tokens []*int, numTokens int) []int {
+	var indices []int
+	for _, index := range tokens {
+		if index != nil && *index >= 0 && *index < numTokens {
+			indices = append(indices, *index)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// documentBounds returns the name and token range of the document containing the token, the whole token list if there is none
+func documentBounds(docs []DocWrapper, tokenIndex int, numTokens int) (string, int, int) {
+	for _, doc := range docs {
+		if doc.BeginIndex == nil || doc.EndIndex == nil {
+			continue
+		}
+		if *doc.BeginIndex <= tokenIndex && tokenIndex < *doc.EndIndex {
+			begin, end := *doc.BeginIndex, *doc.EndIndex
+			if begin < 0 {
+				begin = 0
+			}
+			if end > numTokens {
+				end = numTokens
+			}
+			return doc.Name, begin, end
+		}
+	}
+	return "", 0, numTokens
+}
+
+func joinTokenNames(tokens []Token) string {
+	names := make([]string, len(tokens))
+	for i, token := range tokens {
+		names[i] = token.Name
+	}
+	return strings.Join(names, " ")
+}
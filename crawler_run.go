@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	crawlerRunQueued  = "queued"
+	crawlerRunRunning = "running"
+)
+
+// crawlerRunTransitions lists the statuses a run can move to from each status, finished runs can not change
+var crawlerRunTransitions = map[string][]string{
+	crawlerRunQueued:  {crawlerRunRunning, crawlerRunSucceeded, crawlerRunFailed},
+	crawlerRunRunning: {crawlerRunSucceeded, crawlerRunFailed},
+}
+
+// applyCrawlerRunProgress returns the run updated by the progress report of a worker
+func applyCrawlerRunProgress(run CrawlerJobRun, progress CrawlerRunProgress, now time.Time) (CrawlerJobRun, []ValidationError) {
+	var errs []ValidationError
+	if progress.Status != "" && progress.Status != run.Status {
+		if !containsString(crawlerRunTransitions[run.Status], progress.Status) {
+			errs = append(errs, ValidationError{Field: "status", Message: fmt.Sprintf("a %s run can not become %s", run.Status, progress.Status)})
+		}
+	} else if _, ok := crawlerRunTransitions[run.Status]; !ok {
+		errs = append(errs, ValidationError{Field: "status", Message: fmt.Sprintf("a %s run can not be updated", run.Status)})
+	}
+	if progress.NumberPosts != nil && *progress.NumberPosts < 0 {
+		errs = append(errs, ValidationError{Field: "number_posts", Message: "number of posts must not be negative"})
+	}
+	if progress.NumberComments != nil && *progress.NumberComments < 0 {
+		errs = append(errs, ValidationError{Field: "number_comments", Message: "number of comments must not be negative"})
+	}
+	if len(errs) > 0 {
+		return run, errs
+	}
+
+	if progress.Status != "" && progress.Status != run.Status {
+		run.Status = progress.Status
+		if run.StartedAt == nil {
+			run.StartedAt = &now
+		}
+		if run.Status == crawlerRunSucceeded || run.Status == crawlerRunFailed {
+			run.FinishedAt = &now
+		}
+	}
+	if progress.NumberPosts != nil {
+		run.NumberPosts = *progress.NumberPosts
+	}
+	if progress.NumberComments != nil {
+		run.NumberComments = *progress.NumberComments
+	}
+	if progress.Error != "" {
+		run.Error = progress.Error
+	}
+	if progress.DatasetName != "" {
+		run.DatasetName = progress.DatasetName
+	}
+	if progress.DatasetVersion != nil {
+		run.DatasetVersion = progress.DatasetVersion
+	}
+	return run, nil
+}
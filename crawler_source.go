@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	crawlerSourceReddit    = "reddit"
+	crawlerSourceAppReview = "app_review"
+
+	// crawlers of the legacy job routes
+	crawlerReddit    = "reddit_crawler"
+	crawlerAppReview = "app_review_crawler"
+)
+
+// crawlerRequest is the source specific part of a crawler job
+type crawlerRequest interface {
+	// jobName names the crawled content, e.g. the subreddits or the app
+	jobName() string
+	// targetDataset is the dataset the crawler writes to
+	targetDataset() string
+	// validate checks the settings of the source, the field names are the json names of the request
+	validate() []ValidationError
+}
+
+// crawlerSources creates an empty request for every known source. A new source only needs its request type
+// registered here.
+var crawlerSources = map[string]func() crawlerRequest{
+	crawlerSourceReddit:    func() crawlerRequest { return &CrawlerRequest{} },
+	crawlerSourceAppReview: func() crawlerRequest { return &AppReviewCrawlerRequest{} },
+}
+
+// legacyCrawlerSources maps the crawlers of the legacy job routes to their source
+var legacyCrawlerSources = map[string]string{
+	crawlerReddit:    crawlerSourceReddit,
+	crawlerAppReview: crawlerSourceAppReview,
+}
+
+func (r *CrawlerRequest) jobName() string {
+	return strings.Join(r.Subreddits, ", ")
+}
+
+func (r *CrawlerRequest) targetDataset() string {
+	return r.DatasetName
+}
+
+func (r *AppReviewCrawlerRequest) jobName() string {
+	return r.AppName
+}
+
+func (r *AppReviewCrawlerRequest) targetDataset() string {
+	return r.DatasetName
+}
+
+// decodeCrawlerRequest converts the generic request of a job into the request type of its source
+func decodeCrawlerRequest(source string, request map[string]interface{}) (crawlerRequest, error) {
+	newRequest, ok := crawlerSources[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown crawler source %q", source)
+	}
+	typed := newRequest()
+	if err := convertJSON(request, typed); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// normalizeCrawlerJob replaces the request of a job with its typed form, so stored requests always use the field
+// names of their source, and fills the name and dataset of the job from the request if they are missing
+func normalizeCrawlerJob(job CrawlerJob) (CrawlerJob, crawlerRequest, error) {
+	typed, err := decodeCrawlerRequest(job.Source, job.Request)
+	if err != nil {
+		return job, nil, err
+	}
+	job.Request = map[string]interface{}{}
+	if err := convertBSON(typed, &job.Request); err != nil {
+		return job, nil, err
+	}
+	if job.Name == "" {
+		job.Name = typed.jobName()
+	}
+	if job.DatasetName == "" {
+		job.DatasetName = typed.targetDataset()
+	}
+	return job, typed, nil
+}
+
+// convertJSON copies a value into another type through its json representation
+func convertJSON(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+// convertBSON copies a value into another type through its bson representation, unlike json it keeps integers
+func convertBSON(from interface{}, to interface{}) error {
+	data, err := bson.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, to)
+}
+
+// crawlerJobFromLegacy converts a job of the legacy reddit endpoints
+func crawlerJobFromLegacy(legacy CrawlerJobs) (CrawlerJob, error) {
+	job := CrawlerJob{
+		Source:          crawlerSourceReddit,
+		Name:            legacy.SubredditName,
+		Date:            legacy.Date,
+		Occurrence:      legacy.Occurrence,
+		NumberPosts:     legacy.NumberPosts,
+		DatasetName:     legacy.DatasetName,
+		Schedule:        legacy.Schedule,
+		AppendToDataset: legacy.AppendToDataset,
+	}
+	err := convertBSON(legacy.Request, &job.Request)
+	return job, err
+}
+
+// legacyCrawlerJob converts a reddit job for the legacy endpoints
+func legacyCrawlerJob(job CrawlerJob) CrawlerJobs {
+	legacy := CrawlerJobs{
+		SubredditName:   job.Name,
+		Date:            job.Date,
+		Occurrence:      job.Occurrence,
+		NumberPosts:     job.NumberPosts,
+		DatasetName:     job.DatasetName,
+		Schedule:        job.Schedule,
+		AppendToDataset: job.AppendToDataset,
+	}
+	_ = convertJSON(job.Request, &legacy.Request)
+	return legacy
+}
+
+// appReviewCrawlerJobFromLegacy converts a job of the legacy app review endpoints
+func appReviewCrawlerJobFromLegacy(legacy AppReviewCrawlerJobs) (CrawlerJob, error) {
+	job := CrawlerJob{
+		Source:          crawlerSourceAppReview,
+		Name:            legacy.AppName,
+		Date:            legacy.Date,
+		Occurrence:      legacy.Occurrence,
+		NumberPosts:     legacy.NumberPosts,
+		DatasetName:     legacy.DatasetName,
+		Schedule:        legacy.Schedule,
+		AppendToDataset: legacy.AppendToDataset,
+	}
+	err := convertBSON(legacy.Request, &job.Request)
+	return job, err
+}
+
+// legacyAppReviewCrawlerJob converts an app review job for the legacy endpoints
+func legacyAppReviewCrawlerJob(job CrawlerJob) AppReviewCrawlerJobs {
+	legacy := AppReviewCrawlerJobs{
+		AppName:         job.Name,
+		Date:            job.Date,
+		Occurrence:      job.Occurrence,
+		NumberPosts:     job.NumberPosts,
+		DatasetName:     job.DatasetName,
+		Schedule:        job.Schedule,
+		AppendToDataset: job.AppendToDataset,
+	}
+	_ = convertJSON(job.Request, &legacy.Request)
+	return legacy
+}
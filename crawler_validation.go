@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	maxCommentDepth = 20
+	maxNewLimit     = 1000
+	maxMinLength    = 10000
+)
+
+// redditPostSelections are the reddit listings the crawler can read posts from
+var redditPostSelections = []string{"new", "hot", "top", "rising", "controversial"}
+
+// appReviewPostSelections are the orders the app store review feed can be read in
+var appReviewPostSelections = []string{"mostrecent", "mosthelpful"}
+
+var (
+	subredditPattern = regexp.MustCompile(`^[A-Za-z0-9_]{2,21}$`)
+	appStorePath     = regexp.MustCompile(`^/([a-z]{2}/)?app/([^/]+/)?id[0-9]+/?$`)
+)
+
+// crawlerDateLayouts are the accepted formats of DateFrom and DateTo
+var crawlerDateLayouts = []string{"2006-01-02", time.RFC3339}
+
+func (r *CrawlerRequest) validate() []ValidationError {
+	var errs []ValidationError
+	if len(r.Subreddits) == 0 {
+		errs = append(errs, ValidationError{Field: "subreddits", Message: "at least one subreddit must be given"})
+	}
+	for i, subreddit := range r.Subreddits {
+		if !subredditPattern.MatchString(strings.TrimPrefix(subreddit, "r/")) {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("subreddits[%d]", i), Message: fmt.Sprintf("%q is not a subreddit name", subreddit)})
+		}
+	}
+	if r.DatasetName == "" {
+		errs = append(errs, ValidationError{Field: "dataset_name", Message: "dataset name must not be empty"})
+	}
+	errs = append(errs, validateCrawlerDates(r.DateFrom, r.DateTo)...)
+	errs = append(errs, validateBound("comment_depth", r.CommentDepth, maxCommentDepth)...)
+	errs = append(errs, validateBound("new_limit", r.NewLimit, maxNewLimit)...)
+	errs = append(errs, validateBound("min_length_comments", r.MinLengthComments, maxMinLength)...)
+	errs = append(errs, validateBound("min_length_posts", r.MinLengthPosts, maxMinLength)...)
+	if !containsString(redditPostSelections, r.PostSelection) {
+		errs = append(errs, ValidationError{Field: "post_selection", Message: fmt.Sprintf("post selection must be one of %v", redditPostSelections)})
+	}
+	return errs
+}
+
+func (r *AppReviewCrawlerRequest) validate() []ValidationError {
+	var errs []ValidationError
+	if r.AppName == "" {
+		errs = append(errs, ValidationError{Field: "app_name", Message: "app name must not be empty"})
+	}
+	if !isAppStoreUrl(r.AppUrl) {
+		errs = append(errs, ValidationError{Field: "app_url", Message: "app url must be an app store url like https://apps.apple.com/us/app/name/id123456789"})
+	}
+	if r.DatasetName == "" {
+		errs = append(errs, ValidationError{Field: "dataset_name", Message: "dataset name must not be empty"})
+	}
+	errs = append(errs, validateCrawlerDates(r.DateFrom, r.DateTo)...)
+	errs = append(errs, validateBound("new_limit", r.NewLimit, maxNewLimit)...)
+	errs = append(errs, validateBound("min_length_posts", r.MinLengthPosts, maxMinLength)...)
+	if !containsString(appReviewPostSelections, r.PostSelection) {
+		errs = append(errs, ValidationError{Field: "post_selection", Message: fmt.Sprintf("post selection must be one of %v", appReviewPostSelections)})
+	}
+	return errs
+}
+
+// validateCrawlerJob checks the job and its source specific request, field names of the request are prefixed with
+// "request.". Whether the dataset already exists is checked by the caller.
+func validateCrawlerJob(job CrawlerJob, request crawlerRequest) []ValidationError {
+	var errs []ValidationError
+	if job.Occurrence < 0 {
+		errs = append(errs, ValidationError{Field: "occurrence", Message: "occurrence must not be negative"})
+	}
+	for _, err := range request.validate() {
+		errs = append(errs, ValidationError{Field: "request." + err.Field, Message: err.Message})
+	}
+	if job.DatasetName != request.targetDataset() {
+		errs = append(errs, ValidationError{Field: "dataset_name", Message: "dataset name must match the dataset name of the request"})
+	}
+	return errs
+}
+
+func validateCrawlerDates(from string, to string) []ValidationError {
+	var errs []ValidationError
+	fromDate, fromErr := parseCrawlerDate(from)
+	if fromErr != nil {
+		errs = append(errs, ValidationError{Field: "date_from", Message: fromErr.Error()})
+	}
+	toDate, toErr := parseCrawlerDate(to)
+	if toErr != nil {
+		errs = append(errs, ValidationError{Field: "date_to", Message: toErr.Error()})
+	}
+	if fromErr == nil && toErr == nil && !fromDate.IsZero() && !toDate.IsZero() && toDate.Before(fromDate) {
+		errs = append(errs, ValidationError{Field: "date_to", Message: "date to must not be before date from"})
+	}
+	return errs
+}
+
+// parseCrawlerDate parses an optional crawler date, the zero time is returned for an empty date
+func parseCrawlerDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range crawlerDateLayouts {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date like 2021-03-31", date)
+}
+
+func validateBound(field string, value int, max int) []ValidationError {
+	if value < 0 || value > max {
+		return []ValidationError{{Field: field, Message: fmt.Sprintf("%s must be between 0 and %d", field, max)}}
+	}
+	return nil
+}
+
+func isAppStoreUrl(appUrl string) bool {
+	parsed, err := url.Parse(appUrl)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return false
+	}
+	if parsed.Host != "apps.apple.com" && parsed.Host != "itunes.apple.com" {
+		return false
+	}
+	return appStorePath.MatchString(parsed.Path)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// metricEvaluation is the key of the evaluation in Result.Metrics, it keeps the scores apart from the metrics the
+// method stored itself
+const metricEvaluation = "evaluation"
+
+// evaluateResult scores the codes and the document topics of a result against a ground truth label set. The codes
+// are compared as a set of concepts with the values of the label set, a value may list several concepts separated by
+// commas or semicolons. The topics are compared as a clustering of the documents with the values as classes.
+func evaluateResult(result Result, groundTruth GroundTruth, now time.Time) (ResultEvaluation, error) {
+	evaluation := ResultEvaluation{GroundTruth: groundTruth.Name, EvaluatedAt: now}
+	if len(result.Codes) > 0 {
+		scores := scoreCodes(result.Codes, groundTruth.Elements)
+		evaluation.Codes = &scores
+	}
+	if len(result.DocTopic) > 0 {
+		if scores, ok := scoreTopics(result.DocTopic, groundTruth.Elements); ok {
+			evaluation.Topics = &scores
+		}
+	}
+	if evaluation.Codes == nil && evaluation.Topics == nil {
+		return evaluation, fmt.Errorf("result has no codes and no document topics of documents in the ground truth")
+	}
+	return evaluation, nil
+}
+
+// metricUpdate returns the fields of the evaluation to set in a result and the scores that were not computed this
+// time to unset, keyed by their path in the result
+func (e ResultEvaluation) metricUpdate() (bson.M, bson.M) {
+	prefix := "metrics." + metricEvaluation + "."
+	set := bson.M{prefix + "ground_truth": e.GroundTruth, prefix + "evaluated_at": e.EvaluatedAt}
+	unset := bson.M{}
+	if e.Codes != nil {
+		set[prefix+"codes"] = e.Codes
+	} else {
+		unset[prefix+"codes"] = ""
+	}
+	if e.Topics != nil {
+		set[prefix+"topics"] = e.Topics
+	} else {
+		unset[prefix+"topics"] = ""
+	}
+	return set, unset
+}
+
+// truthConcepts returns the normalized concepts listed in the values of the label set
+func truthConcepts(elements []TruthElement) map[string]bool {
+	concepts := make(map[string]bool)
+	for _, element := range elements {
+		for _, concept := range strings.FieldsFunc(element.Value, func(r rune) bool { return r == ',' || r == ';' }) {
+			if concept = normalizeTerm(concept); concept != "" {
+				concepts[concept] = true
+			}
+		}
+	}
+	return concepts
+}
+
+func scoreCodes(codes []Code, elements []TruthElement) CodeScores {
+	predicted := make(map[string]bool)
+	for _, code := range codes {
+		if name := normalizeTerm(code.Name); name != "" {
+			predicted[name] = true
+		}
+	}
+	expected := truthConcepts(elements)
+
+	scores := CodeScores{Predicted: len(predicted), Expected: len(expected)}
+	for concept := range predicted {
+		if expected[concept] {
+			scores.TruePositives++
+		}
+	}
+	if scores.Predicted > 0 {
+		scores.Precision = float64(scores.TruePositives) / float64(scores.Predicted)
+	}
+	if scores.Expected > 0 {
+		scores.Recall = float64(scores.TruePositives) / float64(scores.Expected)
+	}
+	if scores.Precision+scores.Recall > 0 {
+		scores.F1 = 2 * scores.Precision * scores.Recall / (scores.Precision + scores.Recall)
+	}
+	return scores
+}
+
+// scoreTopics computes purity and normalized mutual information of the document topics, only documents with a
+// topic and a truth value are scored
+func scoreTopics(docTopic map[string]interface{}, elements []TruthElement) (TopicScores, bool) {
+	var topics, classes []string
+	for _, element := range elements {
+		value, ok := docTopic[element.Id]
+		if !ok {
+			continue
+		}
+		topic, ok := documentTopic(value)
+		if !ok {
+			continue
+		}
+		topics = append(topics, topic)
+		classes = append(classes, strings.TrimSpace(element.Value))
+	}
+	if len(topics) == 0 {
+		return TopicScores{}, false
+	}
+
+	return TopicScores{
+		Documents: len(topics),
+		Purity:    purity(topics, classes),
+		NMI:       normalizedMutualInformation(topics, classes),
+	}, true
+}
+
+// documentTopic returns the topic of a document. Methods store either the topic itself or the topic weights of the
+// document as list or as map, in which case the topic with the highest weight is used.
+func documentTopic(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case []interface{}:
+		best, bestWeight := -1, math.Inf(-1)
+		for i, weight := range v {
+			if w, ok := toFloat(weight); ok && w > bestWeight {
+				best, bestWeight = i, w
+			}
+		}
+		return fmt.Sprint(best), best >= 0
+	case bson.M:
+		return documentTopic(map[string]interface{}(v))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		best, bestWeight := "", math.Inf(-1)
+		for _, key := range keys {
+			if w, ok := toFloat(v[key]); ok && w > bestWeight {
+				best, bestWeight = key, w
+			}
+		}
+		return best, best != ""
+	}
+	if _, ok := toFloat(value); ok {
+		return fmt.Sprint(value), true
+	}
+	return "", false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// contingency counts the documents per cluster and class
+func contingency(clusters []string, classes []string) map[string]map[string]int {
+	table := make(map[string]map[string]int)
+	for i, cluster := range clusters {
+		if table[cluster] == nil {
+			table[cluster] = make(map[string]int)
+		}
+		table[cluster][classes[i]]++
+	}
+	return table
+}
+
+// purity is the share of documents belonging to the most frequent class of their cluster
+func purity(clusters []string, classes []string) float64 {
+	correct := 0
+	for _, counts := range contingency(clusters, classes) {
+		max := 0
+		for _, count := range counts {
+			if count > max {
+				max = count
+			}
+		}
+		correct += max
+	}
+	return float64(correct) / float64(len(clusters))
+}
+
+// normalizedMutualInformation normalizes the mutual information of clusters and classes by the arithmetic mean of
+// their entropies, two trivial partitions are considered identical
+func normalizedMutualInformation(clusters []string, classes []string) float64 {
+	n := float64(len(clusters))
+	clusterSizes := make(map[string]int)
+	classSizes := make(map[string]int)
+	for i := range clusters {
+		clusterSizes[clusters[i]]++
+		classSizes[classes[i]]++
+	}
+
+	mutualInformation := 0.0
+	for cluster, counts := range contingency(clusters, classes) {
+		for class, count := range counts {
+			joint := float64(count) / n
+			mutualInformation += joint * math.Log(joint*n*n/float64(clusterSizes[cluster]*classSizes[class]))
+		}
+	}
+	clusterEntropy, classEntropy := entropy(clusterSizes, n), entropy(classSizes, n)
+	if clusterEntropy == 0 && classEntropy == 0 {
+		return 1
+	}
+	return math.Max(0, mutualInformation/((clusterEntropy+classEntropy)/2))
+}
+
+func entropy(sizes map[string]int, n float64) float64 {
+	h := 0.0
+	for _, size := range sizes {
+		p := float64(size) / n
+		h -= p * math.Log(p)
+	}
+	return h
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// defaultGroundTruth is the label set kept in Dataset.GroundTruth for the clients of the dataset endpoints
+const defaultGroundTruth = "default"
+
+// validateGroundTruth checks that the label set is named and every element refers to a document of the dataset once
+func validateGroundTruth(groundTruth GroundTruth, documents []Document) []ValidationError {
+	var errs []ValidationError
+	if groundTruth.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+	}
+
+	ids := documentIds(documents)
+	seen := make(map[string]bool)
+	for i, element := range groundTruth.Elements {
+		field := fmt.Sprintf("elements[%d].id", i)
+		switch {
+		case !ids[element.Id]:
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("%q is not a document of the dataset", element.Id)})
+		case seen[element.Id]:
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("document %q has more than one truth value", element.Id)})
+		}
+		seen[element.Id] = true
+	}
+	return errs
+}
+
+// validateStoredGroundTruths checks the label sets of a dataset against its new documents, the default label set is
+// replaced together with the documents and not checked
+func validateStoredGroundTruths(groundTruths []GroundTruth, documents []Document) []ValidationError {
+	var errs []ValidationError
+	for _, groundTruth := range groundTruths {
+		if groundTruth.Name == defaultGroundTruth {
+			continue
+		}
+		for _, err := range validateGroundTruth(groundTruth, documents) {
+			err.Field = fmt.Sprintf("groundtruth[%s].%s", groundTruth.Name, err.Field)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func documentIds(documents []Document) map[string]bool {
+	ids := make(map[string]bool)
+	for _, document := range documents {
+		ids[document.Id] = true
+	}
+	return ids
+}
+
+// groundTruthCoverage reports how many documents of the dataset have a truth value in the label set
+func groundTruthCoverage(groundTruth GroundTruth, documents []Document) GroundTruthCoverage {
+	coverage := GroundTruthCoverage{
+		Dataset:   groundTruth.Dataset,
+		Name:      groundTruth.Name,
+		Documents: len(documents),
+		Values:    make(map[string]int),
+		Uncovered: []string{},
+	}
+
+	values := make(map[string]string)
+	for _, element := range groundTruth.Elements {
+		values[element.Id] = element.Value
+	}
+	for _, document := range documents {
+		value, ok := values[document.Id]
+		if !ok {
+			coverage.Uncovered = append(coverage.Uncovered, document.Id)
+			continue
+		}
+		coverage.Covered++
+		coverage.Values[value]++
+	}
+	sort.Strings(coverage.Uncovered)
+	if coverage.Documents > 0 {
+		coverage.Coverage = float64(coverage.Covered) / float64(coverage.Documents)
+	}
+	return coverage
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// documentTextHash identifies a document by its text, ignoring case and whitespace
+func documentTextHash(text string) string {
+	sum := sha256.Sum256([]byte(normalizeTerm(strings.TrimSpace(text))))
+	return hex.EncodeToString(sum[:])
+}
+
+// mergeDocuments returns the incoming documents that are not in the dataset yet, numbered after the existing ones.
+// A document is a duplicate if a document with its id or with the same normalised text exists or was ingested before
+// in the same batch.
+func mergeDocuments(existing []Document, incoming []Document) ([]Document, int) {
+	ids := make(map[string]bool)
+	hashes := make(map[string]bool)
+	next := 0
+	for _, document := range existing {
+		if document.Id != "" {
+			ids[document.Id] = true
+		}
+		hashes[documentTextHash(document.Text)] = true
+		if document.Number >= next {
+			next = document.Number + 1
+		}
+	}
+
+	added := []Document{}
+	duplicates := 0
+	for _, document := range incoming {
+		hash := documentTextHash(document.Text)
+		if (document.Id != "" && ids[document.Id]) || hashes[hash] {
+			duplicates++
+			continue
+		}
+		if document.Id != "" {
+			ids[document.Id] = true
+		}
+		hashes[hash] = true
+		document.Number = next
+		next++
+		added = append(added, document)
+	}
+	return added, duplicates
+}
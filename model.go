@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"regexp"
 	"time"
 
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/validator.v2"
 )
 
@@ -12,6 +15,8 @@ type DocWrapper struct {
 	Name       string `json:"name" bson:"name"`
 	BeginIndex *int   `json:"begin_index" bson:"begin_index"`
 	EndIndex   *int   `json:"end_index" bson:"end_index"`
+	// TextHash identifies the text the document was tokenized from, it is not set for sentences
+	TextHash string `json:"text_hash,omitempty" bson:"text_hash,omitempty"`
 }
 
 type TORERelationship struct {
@@ -27,6 +32,8 @@ type Code struct {
 	Tore                    string `json:"tore" bson:"tore"`
 	Index                   *int   `json:"index" bson:"index"`
 	RelationshipMemberships []*int `json:"relationship_memberships" bson:"relationship_memberships"`
+	// Suggested marks codes proposed by a detection method that an annotator did not accept yet
+	Suggested bool `json:"suggested,omitempty" bson:"suggested,omitempty"`
 }
 
 type Token struct {
@@ -44,6 +51,7 @@ type Annotation struct {
 
 	Name    string `validate:"nonzero" json:"name" bson:"name"`
 	Dataset string `validate:"nonzero" json:"dataset" bson:"dataset"`
+	Scheme  string `json:"scheme" bson:"scheme"`
 
 	Tores 			  []string           `json:"tores" bson:"tores"`
 	ShowRecommendationtore	bool         `json:"show_recommendationtore" bson:"show_recommendationtore"`
@@ -52,6 +60,83 @@ type Annotation struct {
 	Tokens            []Token            `json:"tokens" bson:"tokens"`
 	Codes             []Code             `json:"codes" bson:"codes"`
 	TORERelationships []TORERelationship `json:"tore_relationships" bson:"tore_relationships"`
+	// Sentences are the token ranges of the sentences if sentence tokenization is enabled, named by their document
+	Sentences []DocWrapper `json:"sentences,omitempty" bson:"sentences,omitempty"`
+	// SourceResult is the start time of the detection result the annotation was drafted from
+	SourceResult *time.Time `json:"source_result,omitempty" bson:"source_result,omitempty"`
+	// ClonedFrom is the name of the annotation this annotation was cloned from
+	ClonedFrom string `json:"cloned_from,omitempty" bson:"cloned_from,omitempty"`
+	// Assignee is the annotator working on the annotation, Status the state of the work and DoneDocs the names of the
+	// documents the annotator marked as done. They are omitted if empty so saving the annotation keeps them.
+	Assignee        string     `json:"assignee,omitempty" bson:"assignee,omitempty"`
+	Status          string     `json:"status,omitempty" bson:"status,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
+	DoneDocs        []string   `json:"done_docs,omitempty" bson:"done_docs,omitempty"`
+}
+
+// AnnotationAssignment model, the annotator and status to set for an annotation, empty fields are left unchanged
+type AnnotationAssignment struct {
+	Assignee *string `json:"assignee"`
+	Status   string  `json:"status"`
+}
+
+// AnnotationProgress model, the state of the work on an annotation. Progress is the share of documents with at least
+// one code or marked as done.
+type AnnotationProgress struct {
+	Name            string     `json:"name"`
+	Dataset         string     `json:"dataset"`
+	Assignee        string     `json:"assignee"`
+	Status          string     `json:"status"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	LastUpdated     time.Time  `json:"last_updated"`
+	NumDocs         int        `json:"num_docs"`
+	NumDocsCoded    int        `json:"num_docs_coded"`
+	NumDocsDone     int        `json:"num_docs_done"`
+	NumDocsComplete int        `json:"num_docs_complete"`
+	Progress        float64    `json:"progress"`
+}
+
+// AnnotationCloneRequest model, the name of the clone and the codes to keep. Without codes only the documents and
+// tokens are cloned, with TORE categories only the codes of these categories.
+type AnnotationCloneRequest struct {
+	Name       string   `json:"name"`
+	StripCodes bool     `json:"strip_codes"`
+	Tores      []string `json:"tores"`
+}
+
+// Project model, a research group whose datasets, annotations and results are only visible to its members
+type Project struct {
+	Name        string    `json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	Members     []string  `json:"members" bson:"members"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+// AnnotationInitRequest model, the annotation to create for a dataset. Documents are tokenized with the tagger of the
+// language if the dataset has no tokenized annotation yet.
+type AnnotationInitRequest struct {
+	Name                 string `json:"name"`
+	Dataset              string `json:"dataset"`
+	Scheme               string `json:"scheme"`
+	Language             string `json:"language"`
+	SentenceTokenization bool   `json:"sentence_tokenization"`
+}
+
+// AnnotationDraftRequest model, the annotation to create from the codes of a result
+type AnnotationDraftRequest struct {
+	Name                 string `json:"name"`
+	Scheme               string `json:"scheme"`
+	Language             string `json:"language"`
+	SentenceTokenization bool   `json:"sentence_tokenization"`
+}
+
+// AnnotationDraft model, how the codes of a result were placed in a new annotation
+type AnnotationDraft struct {
+	Annotation string   `json:"annotation"`
+	Dataset    string   `json:"dataset"`
+	Tokenized  bool     `json:"tokenized"`
+	Suggested  int      `json:"suggested"`
+	Unmapped   []string `json:"unmapped"`
 }
 
 type Recommendation struct {
@@ -59,6 +144,141 @@ type Recommendation struct {
 	Torecodes []string `bson:"torecodes" json:"torecodes"`
 }
 
+// RecommendationUpdate model, changes to add or remove torecodes of a single recommendation
+type RecommendationUpdate struct {
+	AddTorecodes    []string `json:"add_torecodes"`
+	RemoveTorecodes []string `json:"remove_torecodes"`
+}
+
+// RecommendationUpdateResponse model, number of recommendations changed by a request
+type RecommendationUpdateResponse struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// RecommendationStatistic model, how often a normalized code name or lemma was assigned to a tore in a scheme
+type RecommendationStatistic struct {
+	Scheme string `json:"scheme" bson:"scheme"`
+	Kind   string `json:"kind" bson:"kind"`
+	Term   string `json:"term" bson:"term"`
+	Tore   string `json:"tore" bson:"tore"`
+	Count  int    `json:"count" bson:"count"`
+}
+
+// ToreSuggestion model, a recommended tore with the share of assignments as confidence
+type ToreSuggestion struct {
+	Tore       string  `json:"tore"`
+	Count      int     `json:"count"`
+	Confidence float64 `json:"confidence"`
+}
+
+// TermRecommendation model, the ranked tore suggestions for a code name or lemma
+type TermRecommendation struct {
+	Term                string           `json:"term"`
+	MatchedBy           string           `json:"matched_by"`
+	Suggestions         []ToreSuggestion `json:"suggestions"`
+	RecommendationTores []string         `json:"recommendation_tores,omitempty"`
+}
+
+// TokenRecommendation model, the ranked tore suggestions for an uncoded token of an annotation
+type TokenRecommendation struct {
+	Index       int              `json:"index"`
+	Name        string           `json:"name"`
+	Lemma       string           `json:"lemma"`
+	MatchedBy   string           `json:"matched_by"`
+	Suggestions []ToreSuggestion `json:"suggestions"`
+}
+
+// RecommendationBatchRequest model, the code names or lemmas and optionally an annotation to get suggestions for
+type RecommendationBatchRequest struct {
+	Terms          []string `json:"terms"`
+	Match          string   `json:"match"`
+	Scheme         string   `json:"scheme"`
+	AnnotationName string   `json:"annotation_name"`
+}
+
+// RecommendationBatchResponse model
+type RecommendationBatchResponse struct {
+	Terms  []TermRecommendation  `json:"terms"`
+	Tokens []TokenRecommendation `json:"tokens"`
+}
+
+// ConcordanceHit model, a code occurrence with the tokens surrounding it in its document
+type ConcordanceHit struct {
+	AnnotationName    string   `json:"annotation_name"`
+	Dataset           string   `json:"dataset"`
+	Document          string   `json:"document"`
+	CodeIndex         *int     `json:"code_index"`
+	CodeName          string   `json:"code_name"`
+	Tore              string   `json:"tore"`
+	RelationshipNames []string `json:"relationship_names"`
+	TokenIndices      []int    `json:"token_indices"`
+	LeftContext       string   `json:"left_context"`
+	Match             string   `json:"match"`
+	RightContext      string   `json:"right_context"`
+	Lemmas            []string `json:"lemmas"`
+}
+
+// AnnotationScheme model, a named label set of tore categories and relationship types. Name is unique
+type AnnotationScheme struct {
+	Name        string    `validate:"nonzero" json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ToreCategory model, a category of the TORE taxonomy. Name is unique per scheme, Level is one of Task, Domain, Interaction or System
+type ToreCategory struct {
+	Scheme      string    `json:"scheme" bson:"scheme"`
+	Name        string    `validate:"nonzero" json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	Color       string    `json:"color" bson:"color"`
+	Level       string    `json:"level" bson:"level"`
+	State       string    `json:"state" bson:"state"`
+	LastUpdated time.Time `json:"last_updated" bson:"last_updated"`
+}
+
+// RelationshipType model, a relationship between a code and target tokens. Name is unique per scheme, empty tore lists allow every category
+type RelationshipType struct {
+	Scheme      string    `json:"scheme" bson:"scheme"`
+	Name        string    `validate:"nonzero" json:"name" bson:"name"`
+	Owner       string    `json:"owner" bson:"owner"`
+	Description string    `json:"description" bson:"description"`
+	SourceTores []string  `json:"source_tores" bson:"source_tores"`
+	TargetTores []string  `json:"target_tores" bson:"target_tores"`
+	LastUpdated time.Time `json:"last_updated" bson:"last_updated"`
+}
+
+// StatisticsCount model, number of occurrences of a tore, code name, lemma or relationship name
+type StatisticsCount struct {
+	Name  string `json:"name" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// DocumentCoverage model, share of coded tokens in one document of an annotation
+type DocumentCoverage struct {
+	AnnotationName string  `json:"annotation_name" bson:"annotation_name"`
+	Document       string  `json:"document" bson:"document"`
+	NumTokens      int     `json:"num_tokens" bson:"num_tokens"`
+	NumCodedTokens int     `json:"num_coded_tokens" bson:"num_coded_tokens"`
+	Coverage       float64 `json:"coverage" bson:"coverage"`
+}
+
+// AnnotationStatistics model, aggregated code statistics of one annotation, a dataset or all annotations
+type AnnotationStatistics struct {
+	NumAnnotations     int                `json:"num_annotations"`
+	NumCodes           int                `json:"num_codes"`
+	NumTokens          int                `json:"num_tokens"`
+	NumCodedTokens     int                `json:"num_coded_tokens"`
+	CodedTokenShare    float64            `json:"coded_token_share"`
+	ToreCounts         []StatisticsCount  `json:"tore_counts"`
+	CodeNameCounts     []StatisticsCount  `json:"code_name_counts"`
+	LemmaCounts        []StatisticsCount  `json:"lemma_counts"`
+	RelationshipCounts []StatisticsCount  `json:"relationship_counts"`
+	DocumentCoverage   []DocumentCoverage `json:"document_coverage"`
+}
+
 // end Annotation model
 // The Agreement model
 
@@ -85,6 +305,7 @@ type Agreement struct {
 
 	Name        string   `validate:"nonzero" json:"name" bson:"name"`
 	Dataset     string   `validate:"nonzero" json:"dataset" bson:"dataset"`
+	Scheme      string   `json:"scheme" bson:"scheme"`
 	Annotations []string `json:"annotation_names" bson:"annotation_names"`
 
 	Docs              []DocWrapper       `json:"docs" bson:"docs"`
@@ -109,12 +330,55 @@ type Dataset struct {
 	GroundTruth []TruthElement `json:"ground_truth" bson:"ground_truth"`
 }
 
+// DatasetIngest model, documents to append to a dataset and the crawler job run that collected them
+type DatasetIngest struct {
+	Documents    []Document    `json:"documents"`
+	CrawlerJobId bson.ObjectId `json:"crawler_job_id,omitempty"`
+	CrawlerRunId bson.ObjectId `json:"crawler_run_id,omitempty"`
+}
+
+// DatasetIngestRecord model, what an ingest added to a dataset
+type DatasetIngestRecord struct {
+	Id         bson.ObjectId `json:"id" bson:"_id"`
+	Dataset    string        `json:"dataset" bson:"dataset"`
+	IngestedAt time.Time     `json:"ingested_at" bson:"ingested_at"`
+	Received   int           `json:"received" bson:"received"`
+	Added      int           `json:"added" bson:"added"`
+	Duplicates int           `json:"duplicates" bson:"duplicates"`
+	Size       int           `json:"size" bson:"size"`
+	Created    bool          `json:"created" bson:"created"`
+	// DatasetVersion is the version of the dataset written by the ingest
+	DatasetVersion int           `json:"dataset_version" bson:"dataset_version"`
+	CrawlerJobId   bson.ObjectId `json:"crawler_job_id,omitempty" bson:"crawler_job_id,omitempty"`
+	CrawlerRunId   bson.ObjectId `json:"crawler_run_id,omitempty" bson:"crawler_run_id,omitempty"`
+}
+
 //TruthElement model
 type TruthElement struct {
 	Id    string `json:"id" bson:"id"`
 	Value string `json:"value"  bson:"value"`
 }
 
+// GroundTruth model, a named set of truth values for the documents of a dataset
+type GroundTruth struct {
+	Dataset     string         `json:"dataset" bson:"dataset"`
+	Name        string         `json:"name" bson:"name"`
+	Description string         `json:"description" bson:"description"`
+	Elements    []TruthElement `json:"elements" bson:"elements"`
+	LastUpdated time.Time      `json:"last_updated" bson:"last_updated"`
+}
+
+// GroundTruthCoverage model, how many documents of a dataset have a truth value in a label set
+type GroundTruthCoverage struct {
+	Dataset   string         `json:"dataset"`
+	Name      string         `json:"name"`
+	Documents int            `json:"documents"`
+	Covered   int            `json:"covered"`
+	Coverage  float64        `json:"coverage"`
+	Values    map[string]int `json:"values"`
+	Uncovered []string       `json:"uncovered"`
+}
+
 // Document model
 type Document struct {
 	Number int    `json:"number" bson:"number"`
@@ -134,6 +398,122 @@ type Result struct {
 	Metrics     map[string]interface{} `json:"metrics" bson:"metrics"`
 	Name        string                 `json:"name" bson:"name"`
 	Codes       []Code   			   `json:"codes" bson:"codes"`
+	StatusChangedAt *time.Time         `json:"status_changed_at,omitempty" bson:"status_changed_at,omitempty"`
+	StatusHistory   []ResultTransition `json:"status_history" bson:"status_history,omitempty"`
+	Tags            []string           `json:"tags" bson:"tags,omitempty"`
+	Notes           string             `json:"notes" bson:"notes,omitempty"`
+	Pinned          bool               `json:"pinned" bson:"pinned"`
+}
+
+// ResultPatch model, the fields of a result to update, fields that are not given are kept
+type ResultPatch struct {
+	Name   *string   `json:"name"`
+	Tags   *[]string `json:"tags"`
+	Notes  *string   `json:"notes"`
+	Pinned *bool     `json:"pinned"`
+}
+
+// ResultTransition model, when a result moved to a status
+type ResultTransition struct {
+	Status string    `json:"status" bson:"status"`
+	At     time.Time `json:"at" bson:"at"`
+	Reason string    `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// ResultStatusChange model, moves a result to another status
+type ResultStatusChange struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// ResultEvaluation model, the scores of a result against a ground truth label set
+type ResultEvaluation struct {
+	GroundTruth string       `json:"ground_truth" bson:"ground_truth"`
+	EvaluatedAt time.Time    `json:"evaluated_at" bson:"evaluated_at"`
+	Codes       *CodeScores  `json:"codes,omitempty" bson:"codes,omitempty"`
+	Topics      *TopicScores `json:"topics,omitempty" bson:"topics,omitempty"`
+}
+
+// CodeScores model, how well the detected concepts match the concepts of the ground truth
+type CodeScores struct {
+	Precision     float64 `json:"precision" bson:"precision"`
+	Recall        float64 `json:"recall" bson:"recall"`
+	F1            float64 `json:"f1" bson:"f1"`
+	TruePositives int     `json:"true_positives" bson:"true_positives"`
+	Predicted     int     `json:"predicted" bson:"predicted"`
+	Expected      int     `json:"expected" bson:"expected"`
+}
+
+// TopicScores model, how well the document topics match the classes of the ground truth
+type TopicScores struct {
+	Purity    float64 `json:"purity" bson:"purity"`
+	NMI       float64 `json:"nmi" bson:"nmi"`
+	Documents int     `json:"documents" bson:"documents"`
+}
+
+// ResultComparisonRequest model, the start times of the results to compare
+type ResultComparisonRequest struct {
+	Results []time.Time `json:"results"`
+}
+
+// ResultComparison model, the values of params and metrics are in the order of the compared results
+type ResultComparison struct {
+	Results []ResultSummary    `json:"results"`
+	Params  []ParamDifference  `json:"params"`
+	Metrics []MetricDifference `json:"metrics"`
+	Codes   CodeComparison     `json:"codes"`
+	Pairs   []ResultPair       `json:"pairs"`
+}
+
+// ResultSummary model, identifies a compared result
+type ResultSummary struct {
+	StartedAt   time.Time `json:"started_at"`
+	Method      string    `json:"method"`
+	Name        string    `json:"name"`
+	DatasetName string    `json:"dataset_name"`
+	Status      string    `json:"status"`
+}
+
+// ParamDifference model, a param that is not the same in all results, null if a result does not have it
+type ParamDifference struct {
+	Name   string    `json:"name"`
+	Values []*string `json:"values"`
+}
+
+// MetricDifference model, a numeric metric of the results, null if a result does not have it
+type MetricDifference struct {
+	Name   string     `json:"name"`
+	Values []*float64 `json:"values"`
+	Delta  float64    `json:"delta"`
+}
+
+// CodeComparison model, the concepts detected by all results and the concepts only detected by a single result
+type CodeComparison struct {
+	Shared []string   `json:"shared"`
+	Unique [][]string `json:"unique"`
+}
+
+// ResultPair model, compares the results at the indices first and second
+type ResultPair struct {
+	First          int             `json:"first"`
+	Second         int             `json:"second"`
+	Jaccard        float64         `json:"jaccard"`
+	TopicAlignment *TopicAlignment `json:"topic_alignment"`
+}
+
+// TopicAlignment model, how the document topics of two results correspond
+type TopicAlignment struct {
+	Documents int          `json:"documents"`
+	NMI       float64      `json:"nmi"`
+	Matches   []TopicMatch `json:"matches"`
+}
+
+// TopicMatch model, the topic of the second result sharing the most documents with a topic of the first result,
+// the overlap is the Jaccard index of their documents
+type TopicMatch struct {
+	Topic   string  `json:"topic"`
+	Match   string  `json:"match"`
+	Overlap float64 `json:"overlap"`
 }
 
 // ResponseMessage model
@@ -142,6 +522,19 @@ type ResponseMessage struct {
 	Status  bool   `json:"status"`
 }
 
+// ValidationError model, describes why a single field of a request is invalid
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationResponse model, a ResponseMessage listing all validation errors
+type ValidationResponse struct {
+	Message string            `json:"message"`
+	Status  bool              `json:"status"`
+	Errors  []ValidationError `json:"errors"`
+}
+
 // Date model, helper for parsing dates
 type Date struct {
 	Date time.Time `json:"date"`
@@ -164,7 +557,7 @@ type CrawlerRequest struct {
 }
 
 
-// Crawler Jobs model
+// Crawler Jobs model, the reddit jobs of the legacy crawler job endpoints
 type CrawlerJobs struct {
 	SubredditName string    `validate:"nonzero" json:"subreddit_names" bson:"subreddit_names"`
 	Date          time.Time `validate:"nonzero" json:"date" bson:"date"`
@@ -172,6 +565,8 @@ type CrawlerJobs struct {
 	NumberPosts   int       `json:"number_posts" bson:"number_posts"`
 	DatasetName   string    `validate:"nonzero" json:"dataset_name" bson:"dataset_name"`
 	Request       CrawlerRequest `json:"request" bson:"request"` 
+	Schedule      *CrawlerSchedule `json:"schedule,omitempty" bson:"schedule,omitempty"`
+	AppendToDataset bool `json:"append_to_dataset" bson:"append_to_dataset"`
 }
 
 type AppReviewCrawlerRequest struct {
@@ -188,6 +583,7 @@ type AppReviewCrawlerRequest struct {
 	ReplaceEmojis bool `json:"replace_emojis" bson:"replace_emojis"`
 }
 
+// AppReviewCrawlerJobs model, the app review jobs of the legacy crawler job endpoints
 type AppReviewCrawlerJobs struct {
 	AppName string `validate:"nonzero" json:"app_name" bson:"app_name"`
 	Date time.Time `validate:"nonzero" json:"date" bson:"date"`
@@ -195,8 +591,117 @@ type AppReviewCrawlerJobs struct {
 	NumberPosts int `json:"app_number_posts" bson:"app_number_posts"`
 	DatasetName string `validate:"nonzero" json:"dataset_name" bson:"dataset_name"`
 	Request AppReviewCrawlerRequest `json:"request" bson:"request"`
+	Schedule *CrawlerSchedule `json:"schedule,omitempty" bson:"schedule,omitempty"`
+	AppendToDataset bool `json:"append_to_dataset" bson:"append_to_dataset"`
+}
+
+// CrawlerJob model, a job of any crawler source. The request holds the source specific crawler settings, e.g. a
+// CrawlerRequest for reddit or an AppReviewCrawlerRequest for app reviews.
+type CrawlerJob struct {
+	Id          bson.ObjectId          `json:"id" bson:"_id"`
+	Source      string                 `json:"source" bson:"source"`
+	Name        string                 `json:"name" bson:"name"`
+	Date        time.Time              `json:"date" bson:"date"`
+	Occurrence  int                    `json:"occurrence" bson:"occurrence"`
+	NumberPosts int                    `json:"number_posts" bson:"number_posts"`
+	DatasetName string                 `json:"dataset_name" bson:"dataset_name"`
+	Request     map[string]interface{} `json:"request" bson:"request"`
+	Schedule    *CrawlerSchedule       `json:"schedule,omitempty" bson:"schedule,omitempty"`
+
+	// AppendToDataset allows the crawler to write to an existing dataset
+	AppendToDataset bool `json:"append_to_dataset" bson:"append_to_dataset"`
+}
+
+// CrawlerSchedule model, when a crawler job runs, how its last run ended and which worker currently holds it
+type CrawlerSchedule struct {
+	IntervalMinutes int        `json:"interval_minutes" bson:"interval_minutes"`
+	Cron            string     `json:"cron" bson:"cron"`
+	NextRun         *time.Time `json:"next_run,omitempty" bson:"next_run,omitempty"`
+	LastRun         *time.Time `json:"last_run,omitempty" bson:"last_run,omitempty"`
+	LastOutcome     string     `json:"last_outcome" bson:"last_outcome"`
+	LastError       string     `json:"last_error" bson:"last_error"`
+	LockedBy        string     `json:"locked_by" bson:"locked_by"`
+	LockedUntil     *time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+}
+
+// CrawlerJobRun model, one run of a crawler job and what it collected
+type CrawlerJobRun struct {
+	Id             bson.ObjectId `json:"id" bson:"_id"`
+	JobId          bson.ObjectId `json:"job_id" bson:"job_id"`
+	Source         string        `json:"source" bson:"source"`
+	Status         string        `json:"status" bson:"status"`
+	Worker         string        `json:"worker" bson:"worker"`
+	QueuedAt       time.Time     `json:"queued_at" bson:"queued_at"`
+	StartedAt      *time.Time    `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	FinishedAt     *time.Time    `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+	NumberPosts    int           `json:"number_posts" bson:"number_posts"`
+	NumberComments int           `json:"number_comments" bson:"number_comments"`
+	Error          string        `json:"error" bson:"error"`
+	DatasetName    string        `json:"dataset_name" bson:"dataset_name"`
+	DatasetVersion *time.Time    `json:"dataset_version,omitempty" bson:"dataset_version,omitempty"`
+	// IngestedAt is the time the run last ingested documents into its dataset
+	IngestedAt     *time.Time    `json:"ingested_at,omitempty" bson:"ingested_at,omitempty"`
+}
+
+// CrawlerRunProgress model, reported by a worker while it runs a job, unset fields are left unchanged
+type CrawlerRunProgress struct {
+	Status         string     `json:"status"`
+	NumberPosts    *int       `json:"number_posts"`
+	NumberComments *int       `json:"number_comments"`
+	Error          string     `json:"error"`
+	DatasetName    string     `json:"dataset_name"`
+	DatasetVersion *time.Time `json:"dataset_version"`
+}
+
+// DueCrawlerJobsRequest model, a worker asking for due jobs to run
+type DueCrawlerJobsRequest struct {
+	Worker       string `json:"worker"`
+	LeaseSeconds int    `json:"lease_seconds"`
+	Limit        int    `json:"limit"`
+}
+
+// DueCrawlerJobs model, the jobs leased to a worker
+type DueCrawlerJobs struct {
+	Jobs []CrawlerJob `json:"jobs"`
+}
+
+// CrawlerRunOutcome model, reported by a worker when it finished a leased job
+type CrawlerRunOutcome struct {
+	Worker  string `json:"worker"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error"`
+}
+
+const (
+	defaultScheme = "default"
+
+	toreLevelTask        = "Task"
+	toreLevelDomain      = "Domain"
+	toreLevelInteraction = "Interaction"
+	toreLevelSystem      = "System"
+
+	toreCategoryActive     = "active"
+	toreCategoryDeprecated = "deprecated"
+)
+
+var toreLevels = []string{toreLevelTask, toreLevelDomain, toreLevelInteraction, toreLevelSystem}
+
+// defaultToreLevels assigns the categories of the TORE framework to their level
+var defaultToreLevels = map[string]string{
+	"Stakeholder":      toreLevelTask,
+	"Goal":             toreLevelTask,
+	"Task":             toreLevelTask,
+	"Activity":         toreLevelDomain,
+	"Domain Data":      toreLevelDomain,
+	"Interaction":      toreLevelInteraction,
+	"Interaction Data": toreLevelInteraction,
+	"Workspace":        toreLevelInteraction,
+	"System Function":  toreLevelSystem,
+	"Software":         toreLevelSystem,
 }
 
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
 func (result *Result) validate() error {
 	return validator.Validate(result)
 }
@@ -209,6 +714,78 @@ func (document *Document) validate() error {
 	return validator.Validate(document)
 }
 
+func (category *ToreCategory) validate() error {
+	return validator.Validate(category)
+}
+
+func validateToreCategory(category ToreCategory) []ValidationError {
+	var errs []ValidationError
+	if err := category.validate(); err != nil {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+	}
+	if category.Level != "" && !containsString(toreLevels, category.Level) {
+		errs = append(errs, ValidationError{Field: "level", Message: fmt.Sprintf("level must be one of %v", toreLevels)})
+	}
+	if category.Color != "" && !colorPattern.MatchString(category.Color) {
+		errs = append(errs, ValidationError{Field: "color", Message: "color must be a hex color like #1f77b4"})
+	}
+	if category.State != toreCategoryActive && category.State != toreCategoryDeprecated {
+		errs = append(errs, ValidationError{Field: "state", Message: fmt.Sprintf("state must be %s or %s", toreCategoryActive, toreCategoryDeprecated)})
+	}
+	return errs
+}
+
+func (relationshipType *RelationshipType) validate() error {
+	return validator.Validate(relationshipType)
+}
+
+func validateRelationshipType(relationshipType RelationshipType, categories []ToreCategory) []ValidationError {
+	var errs []ValidationError
+	if err := relationshipType.validate(); err != nil {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+	}
+	if len(categories) == 0 {
+		return errs
+	}
+
+	var names []string
+	for _, category := range categories {
+		names = append(names, category.Name)
+	}
+	for i, tore := range relationshipType.SourceTores {
+		if !containsString(names, tore) {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("source_tores[%d]", i), Message: fmt.Sprintf("%q is not a TORE category", tore)})
+		}
+	}
+	for i, tore := range relationshipType.TargetTores {
+		if !containsString(names, tore) {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("target_tores[%d]", i), Message: fmt.Sprintf("%q is not a TORE category", tore)})
+		}
+	}
+	return errs
+}
+
+func (scheme *AnnotationScheme) validate() error {
+	return validator.Validate(scheme)
+}
+
+// schemeOrDefault returns the default scheme for annotations, agreements and requests without a scheme
+func schemeOrDefault(scheme string) string {
+	if scheme == "" {
+		return defaultScheme
+	}
+	return scheme
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func validateDataset(dataset Dataset) error {
 
 	err := dataset.validate()
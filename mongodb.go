@@ -3,14 +3,18 @@ package main
 import (
 	"fmt"
 	"log"
+	"regexp"
 	"time"
 
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
+// database is the database of the default project and of the project registry, configured with MONGO_DATABASE. The
+// other projects are stored in databases named after it, see projectDatabase.
+var database = "concepts_data"
+
 const (
-	database                = "concepts_data"
 	collectionDataset       = "dataset"
 	collectionResult        = "result"
 	collectionAnnotation    = "annotation"
@@ -20,6 +24,17 @@ const (
 	collectionCrawlerJobs   = "crawler_jobs2"
 	collectionAppReviewCrawlerJobs = "crawler_jobs_for_app_reviews3"
 	collectionRecommendation       = "recommendation"
+	collectionRecommendationStage  = "recommendation_staging"
+	collectionToreCategories       = "tore_category"
+	collectionRelationshipTypes    = "relationship_type"
+	collectionAnnotationSchemes    = "annotation_scheme"
+	collectionRecommendationStats  = "recommendation_statistic"
+	collectionCrawlerJob           = "crawler_job"
+	collectionCrawlerJobRuns       = "crawler_job_run"
+	collectionDatasetIngests       = "dataset_ingest"
+	collectionGroundTruth          = "ground_truth"
+	collectionProject              = "project"
+	collectionMigration            = "migration"
 
 	fieldRelationshipNames = "relationship_names"
 	fieldToreTypes         = "tores"
@@ -27,11 +42,32 @@ const (
 	fieldAgreementName     = "name"
 	fieldDatasetName       = "name"
 	fieldDatasetUploadedAt = "uploaded_at"
+	fieldDatasetVersion    = "version"
 	fieldResultStartedAt   = "started_at"
 	fieldResultMethodName  = "method"
+	fieldResultStatus      = "status"
+	fieldResultStatusChangedAt = "status_changed_at"
+	fieldResultTags            = "tags"
+	fieldResultPinned          = "pinned"
 	fieldCrawlerJobName    = "DatasetName"
 	fieldCrawlerJobDate    = "date"
+	fieldCrawlerJobSource      = "source"
+	fieldCrawlerJobNextRun     = "schedule.next_run"
+	fieldCrawlerJobLockedBy    = "schedule.locked_by"
+	fieldCrawlerJobLockedUntil = "schedule.locked_until"
 	fieldRecommendationCodename = "codename"
+	fieldToreCategoryName       = "name"
+	fieldToreCategoryState      = "state"
+	fieldRelationshipTypeName   = "name"
+	fieldSchemeName             = "name"
+	fieldScheme                 = "scheme"
+	fieldAnnotationAssignee     = "assignee"
+	fieldAnnotationStatus       = "status"
+	fieldAnnotationDoneDocs     = "done_docs"
+	fieldProjectName            = "name"
+	fieldProjectMembers         = "members"
+
+	migrationRecommendationStatistics = "recommendation_statistics"
 )
 
 func panicError(err error) {
@@ -71,7 +107,7 @@ func MongoGetSession(mongoIP, username, password string, db string) *mgo.Session
 }
 
 // MongoCreateCollectionIndexes creates the indexes
-func MongoCreateCollectionIndexes(mongoClient *mgo.Session) {
+func MongoCreateCollectionIndexes(db *mgo.Database) {
 	// Index
 	datasetIndex := mgo.Index{
 		Key:        []string{fieldDatasetName},
@@ -79,7 +115,7 @@ func MongoCreateCollectionIndexes(mongoClient *mgo.Session) {
 		Background: true,
 		Sparse:     true,
 	}
-	datasetCollection := mongoClient.DB(database).C(collectionDataset)
+	datasetCollection := db.C(collectionDataset)
 	err := datasetCollection.EnsureIndex(datasetIndex)
 	panicError(err)
 	// Index
@@ -98,9 +134,11 @@ func MongoCreateCollectionIndexes(mongoClient *mgo.Session) {
 		Background: true,
 		Sparse:     true,
 	}
-	resultCollection := mongoClient.DB(database).C(collectionResult)
+	resultCollection := db.C(collectionResult)
 	err = resultCollection.EnsureIndex(resultIndex)
 	panicError(err)
+	err = resultCollection.EnsureIndex(mgo.Index{Key: []string{fieldResultTags}, Background: true})
+	panicError(err)
 
 	// Index Recommendation
     recomendationIndex := mgo.Index{
@@ -109,33 +147,402 @@ func MongoCreateCollectionIndexes(mongoClient *mgo.Session) {
         Background: true,
         Sparse:     true,
     }
-    recomendationCollection := mongoClient.DB(database).C(collectionRecommendation)
+    recomendationCollection := db.C(collectionRecommendation)
     err = recomendationCollection.EnsureIndex(recomendationIndex)
     panicError(err)
+
+	// Index Recommendation Statistics
+	err = db.C(collectionRecommendationStats).EnsureIndex(recommendationStatisticIndex)
+	panicError(err)
+
+	// Index Annotation assignees for the work queues
+	err = db.C(collectionAnnotation).EnsureIndex(mgo.Index{Key: []string{fieldAnnotationAssignee, fieldAnnotationStatus}, Background: true})
+	panicError(err)
+
+	// Index Annotation Schemes
+	schemeIndex := mgo.Index{
+		Key:        []string{fieldSchemeName},
+		Unique:     true,
+		Background: true,
+		Sparse:     true,
+	}
+	err = db.C(collectionAnnotationSchemes).EnsureIndex(schemeIndex)
+	panicError(err)
+
+	// Index Tore Categories, names are unique per scheme
+	toreCategoryCollection := db.C(collectionToreCategories)
+	_ = toreCategoryCollection.DropIndex(fieldToreCategoryName)
+	toreCategoryIndex := mgo.Index{
+		Key:        []string{fieldScheme, fieldToreCategoryName},
+		Unique:     true,
+		Background: true,
+		Sparse:     true,
+	}
+	err = toreCategoryCollection.EnsureIndex(toreCategoryIndex)
+	panicError(err)
+
+	// Index Relationship Types, names are unique per scheme
+	relationshipTypeCollection := db.C(collectionRelationshipTypes)
+	_ = relationshipTypeCollection.DropIndex(fieldRelationshipTypeName)
+	relationshipTypeIndex := mgo.Index{
+		Key:        []string{fieldScheme, fieldRelationshipTypeName},
+		Unique:     true,
+		Background: true,
+		Sparse:     true,
+	}
+	err = relationshipTypeCollection.EnsureIndex(relationshipTypeIndex)
+	panicError(err)
+
+	// Index Crawler Jobs, jobs are listed by source and workers look up due jobs by their next run
+	crawlerJobCollection := db.C(collectionCrawlerJob)
+	err = crawlerJobCollection.EnsureIndex(mgo.Index{Key: []string{fieldCrawlerJobSource, fieldCrawlerJobDate}, Background: true})
+	panicError(err)
+	err = crawlerJobCollection.EnsureIndex(mgo.Index{Key: []string{fieldCrawlerJobNextRun}, Background: true, Sparse: true})
+	panicError(err)
+
+	// Index Dataset Ingests, ingests are listed per dataset
+	err = db.C(collectionDatasetIngests).EnsureIndex(mgo.Index{Key: []string{"dataset", "-ingested_at"}, Background: true})
+	panicError(err)
+
+	// Index Ground Truth, label set names are unique per dataset
+	err = db.C(collectionGroundTruth).EnsureIndex(mgo.Index{Key: []string{"dataset", "name"}, Unique: true, Background: true})
+	panicError(err)
+
+	// Index Crawler Job Runs, runs are listed per job
+	err = db.C(collectionCrawlerJobRuns).EnsureIndex(mgo.Index{Key: []string{"job_id", "-queued_at"}, Background: true})
+	panicError(err)
+}
+
+// MongoMigrateGroundTruth turns the ground truth stored in datasets into their default label set
+func MongoMigrateGroundTruth(db *mgo.Database) {
+	var datasets []Dataset
+	err := db.C(collectionDataset).
+		Find(bson.M{"ground_truth.0": bson.M{"$exists": true}}).
+		Select(bson.M{fieldDatasetName: 1, "ground_truth": 1}).
+		All(&datasets)
+	panicError(err)
+
+	migrated := 0
+	for _, dataset := range datasets {
+		groundTruth := GroundTruth{Dataset: dataset.Name, Name: defaultGroundTruth, Elements: dataset.GroundTruth, LastUpdated: time.Now()}
+		info, err := db.C(collectionGroundTruth).Upsert(
+			bson.M{"dataset": dataset.Name, "name": defaultGroundTruth},
+			bson.M{"$setOnInsert": groundTruth},
+		)
+		panicError(err)
+		if info.UpsertedId != nil {
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		fmt.Printf("Migrated the ground truth of %d datasets into label sets\n", migrated)
+	}
+}
+
+// MongoMigrateCrawlerJobs moves the jobs of the legacy reddit and app review collections into the crawler job
+// collection and links their runs to the moved jobs. The moved legacy documents are kept in collections with a
+// "_migrated" suffix, documents that can not be converted are logged and stay in their legacy collection.
+func MongoMigrateCrawlerJobs(db *mgo.Database) {
+	legacyCrawlers := map[string]string{crawlerSourceReddit: crawlerReddit, crawlerSourceAppReview: crawlerAppReview}
+	migrated := 0
+	for _, collection := range []string{collectionCrawlerJobs, collectionAppReviewCrawlerJobs} {
+		var documents []bson.M
+		panicError(db.C(collection).Find(nil).All(&documents))
+		for _, document := range documents {
+			// the jobs keep the ids of their legacy documents, so an interrupted migration can be repeated
+			job, err := crawlerJobFromLegacyDocument(collection, document)
+			if err != nil {
+				fmt.Printf("ERROR migrating crawler job %v of %s: %s\n", document["_id"], collection, err)
+				continue
+			}
+			_, err = db.C(collectionCrawlerJob).UpsertId(job.Id, job)
+			panicError(err)
+			_, err = db.C(collectionCrawlerJobRuns).UpdateAll(
+				bson.M{"crawler": legacyCrawlers[job.Source], "job_date": job.Date},
+				bson.M{"$set": bson.M{"job_id": job.Id, "source": job.Source}, "$unset": bson.M{"crawler": "", "job_date": ""}},
+			)
+			panicError(err)
+			_, err = db.C(collection+"_migrated").UpsertId(job.Id, document)
+			panicError(err)
+			panicError(db.C(collection).RemoveId(job.Id))
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		fmt.Printf("Migrated %d legacy crawler jobs\n", migrated)
+	}
+}
+
+// crawlerJobFromLegacyDocument converts a document of a legacy crawler job collection into a normalized job with the
+// id of the document. The request is taken as stored, as the typed legacy request skips fields of another type.
+func crawlerJobFromLegacyDocument(collection string, document bson.M) (CrawlerJob, error) {
+	id, ok := document["_id"].(bson.ObjectId)
+	if !ok {
+		return CrawlerJob{}, fmt.Errorf("id %v is not an object id", document["_id"])
+	}
+	request, ok := document["request"].(bson.M)
+	if !ok {
+		return CrawlerJob{}, fmt.Errorf("request %v is not a document", document["request"])
+	}
+
+	var job CrawlerJob
+	var err error
+	if collection == collectionAppReviewCrawlerJobs {
+		var legacy AppReviewCrawlerJobs
+		if err = convertBSON(document, &legacy); err == nil {
+			job, err = appReviewCrawlerJobFromLegacy(legacy)
+		}
+	} else {
+		var legacy CrawlerJobs
+		if err = convertBSON(document, &legacy); err == nil {
+			job, err = crawlerJobFromLegacy(legacy)
+		}
+	}
+	if err != nil {
+		return job, err
+	}
+	job.Id, job.Request = id, request
+	job, _, err = normalizeCrawlerJob(job)
+	return job, err
+}
+
+// MongoMigrateCrawlerSchedules gives the jobs that repeat by their legacy occurrence a schedule
+func MongoMigrateCrawlerSchedules(db *mgo.Database) {
+	var jobs []CrawlerJob
+	err := db.C(collectionCrawlerJob).
+		Find(bson.M{"schedule": bson.M{"$exists": false}, "occurrence": bson.M{"$gt": 0}}).
+		All(&jobs)
+	panicError(err)
+
+	now := time.Now()
+	for _, job := range jobs {
+		schedule := legacyCrawlerSchedule(job.Occurrence)
+		schedule.NextRun = nextCrawlerRun(*schedule, now)
+		err = db.C(collectionCrawlerJob).UpdateId(job.Id, bson.M{"$set": bson.M{"schedule": schedule}})
+		panicError(err)
+	}
+	if len(jobs) > 0 {
+		fmt.Printf("Scheduled %d crawler jobs by their occurrence\n", len(jobs))
+	}
+}
+
+// MongoMigrateAnnotationSchemes creates the default scheme and binds all annotations, agreements, tore categories
+// and relationship types without a scheme to it
+func MongoMigrateAnnotationSchemes(db *mgo.Database) {
+	_, err := db.C(collectionAnnotationSchemes).Upsert(
+		bson.M{fieldSchemeName: defaultScheme},
+		bson.M{"$setOnInsert": AnnotationScheme{Name: defaultScheme, Description: "Default annotation scheme", CreatedAt: time.Now()}},
+	)
+	panicError(err)
+
+	for _, collection := range []string{collectionAnnotation, collectionAgreement, collectionToreCategories, collectionRelationshipTypes} {
+		info, err := db.C(collection).UpdateAll(bson.M{fieldScheme: bson.M{"$exists": false}}, bson.M{"$set": bson.M{fieldScheme: defaultScheme}})
+		panicError(err)
+		if info.Updated > 0 {
+			fmt.Printf("Bound %d documents of %s to the default annotation scheme\n", info.Updated, collection)
+		}
+	}
+}
+
+// MongoMigrateToreTypes creates the tore categories from the legacy list of tore names, if no categories exist yet
+func MongoMigrateToreTypes(db *mgo.Database) {
+	count, err := db.C(collectionToreCategories).Find(bson.M{fieldScheme: defaultScheme}).Count()
+	panicError(err)
+	if count > 0 {
+		return
+	}
+
+	names := bson.M{"names": new([]string)}
+	err = db.
+		C(collectionTores).Find(bson.M{fieldToreTypes: fieldToreTypes}).One(&names)
+	if err == mgo.ErrNotFound {
+		return
+	}
+	panicError(err)
+
+	var tores []string
+	for _, value := range names["names"].([]interface{}) {
+		tores = append(tores, value.(string))
+	}
+	fmt.Printf("Migrating %d tore types to tore categories\n", len(tores))
+	err = MongoPostAllTORE(db, defaultScheme, tores)
+	panicError(err)
+}
+
+// MongoMigrateRelationshipNames creates the relationship types from the legacy name and owner lists, if no types exist yet
+func MongoMigrateRelationshipNames(db *mgo.Database) {
+	count, err := db.C(collectionRelationshipTypes).Find(bson.M{fieldScheme: defaultScheme}).Count()
+	panicError(err)
+	if count > 0 {
+		return
+	}
+
+	var legacy struct {
+		Names  []string `bson:"names"`
+		Owners []string `bson:"owners"`
+	}
+	err = db.
+		C(collectionRelationships).Find(bson.M{fieldRelationshipNames: fieldRelationshipNames}).One(&legacy)
+	if err == mgo.ErrNotFound {
+		return
+	}
+	panicError(err)
+
+	owners := make([]string, len(legacy.Names))
+	copy(owners, legacy.Owners)
+	fmt.Printf("Migrating %d relationship names to relationship types\n", len(legacy.Names))
+	err = MongoPostAllRelationshipNames(db, defaultScheme, legacy.Names, owners)
+	panicError(err)
+}
+
+// MongoMigrateRecommendationStatistics builds the recommendation statistics from the existing annotations once
+func MongoMigrateRecommendationStatistics(db *mgo.Database) {
+	count, err := db.C(collectionMigration).FindId(migrationRecommendationStatistics).Count()
+	panicError(err)
+	if count > 0 {
+		return
+	}
+
+	count, err = MongoRebuildRecommendationStatistics(db)
+	panicError(err)
+	fmt.Printf("Migrated %d recommendation statistics\n", count)
+	err = db.C(collectionMigration).Insert(bson.M{"_id": migrationRecommendationStatistics, "migrated_at": time.Now()})
+	panicError(err)
 }
 
+// annotationCodesFields selects the scheme, tokens and codes of annotations the recommendation statistics are counted from
+var annotationCodesFields = bson.M{"scheme": 1, "tokens": 1, "codes": 1}
+
 // MongoInsertAnnotation returns ok if the annotation was inserted or already existed
-func MongoInsertAnnotation(mongoClient *mgo.Session, annotation Annotation) error {
+func MongoInsertAnnotation(db *mgo.Database, annotation Annotation) error {
 	annotation.LastUpdated = time.Now()
-	query := bson.M{fieldAnnotationName: annotation.Name}
-	update := bson.M{"$set": annotation}
-	_, err := mongoClient.DB(database).C(collectionAnnotation).Upsert(query, update)
-	if err != nil && !mgo.IsDup(err) {
+	// the replaced version is returned by the same operation, so concurrent saves each count their own changes
+	var previous Annotation
+	change := mgo.Change{Update: bson.M{"$set": annotation}, Upsert: true}
+	query := db.
+		C(collectionAnnotation).
+		Find(bson.M{fieldAnnotationName: annotation.Name}).
+		Select(annotationCodesFields)
+	_, err := query.Apply(change, &previous)
+	if mgo.IsDup(err) {
+		// a concurrent save inserted the annotation first, the retry updates it
+		previous = Annotation{}
+		_, err = query.Apply(change, &previous)
+	}
+	if err != nil {
 		fmt.Println(err)
 		return err
 	}
 
+	mongoUpdateRecommendationStatistics(db, diffRecommendationCounts(recommendationCounts(previous), recommendationCounts(annotation)))
 	return nil
 }
 
+// mongoUpdateRecommendationStatistics applies count changes to the recommendation statistics, errors are only logged
+// because the statistics can always be rebuilt from the annotations
+func mongoUpdateRecommendationStatistics(db *mgo.Database, deltas map[recommendationKey]int) {
+	if len(deltas) == 0 {
+		return
+	}
+	collection := db.C(collectionRecommendationStats)
+	for key, delta := range deltas {
+		query := bson.M{fieldScheme: key.Scheme, "kind": key.Kind, "term": key.Term, "tore": key.Tore}
+		_, err := collection.Upsert(query, bson.M{"$inc": bson.M{"count": delta}})
+		if err != nil {
+			fmt.Printf("Error updating recommendation statistics: %v\n", err)
+		}
+	}
+	_, err := collection.RemoveAll(bson.M{"count": bson.M{"$lte": 0}})
+	if err != nil {
+		fmt.Printf("Error updating recommendation statistics: %v\n", err)
+	}
+}
+
+var recommendationStatisticIndex = mgo.Index{
+	Key:        []string{fieldScheme, "kind", "term", "tore"},
+	Unique:     true,
+	Background: true,
+}
+
+// MongoRebuildRecommendationStatistics recomputes the recommendation statistics from all annotations. They are built
+// in a staging collection that replaces the statistics at once.
+func MongoRebuildRecommendationStatistics(db *mgo.Database) (int, error) {
+	counts := make(map[recommendationKey]int)
+	var annotation Annotation
+	iter := db.
+		C(collectionAnnotation).
+		Find(bson.M{}).
+		Select(annotationCodesFields).
+		Iter()
+	for iter.Next(&annotation) {
+		for key, count := range recommendationCounts(annotation) {
+			counts[key] += count
+		}
+		annotation = Annotation{}
+	}
+	if err := iter.Close(); err != nil {
+		return 0, err
+	}
+
+	staging := db.C(collectionRecommendationStats + "_" + bson.NewObjectId().Hex())
+	if err := staging.EnsureIndex(recommendationStatisticIndex); err != nil {
+		_ = staging.DropCollection()
+		return 0, err
+	}
+	docs := make([]interface{}, 0, len(counts))
+	for key, count := range counts {
+		docs = append(docs, RecommendationStatistic{Scheme: key.Scheme, Kind: key.Kind, Term: key.Term, Tore: key.Tore, Count: count})
+	}
+	if len(docs) > 0 {
+		if err := staging.Insert(docs...); err != nil {
+			_ = staging.DropCollection()
+			return 0, err
+		}
+	}
+	if err := mongoReplaceCollection(db, staging, collectionRecommendationStats); err != nil {
+		_ = staging.DropCollection()
+		return 0, err
+	}
+	return len(docs), nil
+}
+
+// MongoGetRecommendationStatistics returns the tore counts of a normalized term
+func MongoGetRecommendationStatistics(db *mgo.Database, scheme string, kind string, term string) []RecommendationStatistic {
+	var statistics []RecommendationStatistic
+	err := db.
+		C(collectionRecommendationStats).
+		Find(bson.M{fieldScheme: scheme, "kind": kind, "term": normalizeTerm(term)}).
+		All(&statistics)
+	panicError(err)
+
+	return statistics
+}
+
+// MongoGetRecommendationStatisticsForTerms returns the statistics of all kinds for any of the terms
+func MongoGetRecommendationStatisticsForTerms(db *mgo.Database, scheme string, terms []string) []RecommendationStatistic {
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		normalized = append(normalized, normalizeTerm(term))
+	}
+
+	var statistics []RecommendationStatistic
+	err := db.
+		C(collectionRecommendationStats).
+		Find(bson.M{fieldScheme: scheme, "term": bson.M{"$in": normalized}}).
+		All(&statistics)
+	panicError(err)
+
+	return statistics
+}
+
 // MongoInsertAgreement returns ok if the agreement was inserted or already existed
-func MongoInsertAgreement(mongoClient *mgo.Session, agreement Agreement) error {
+func MongoInsertAgreement(db *mgo.Database, agreement Agreement) error {
 	agreement.LastUpdated = time.Now()
 	var isCompleted = calculateIsCompleted(agreement)
 	agreement.IsCompleted = isCompleted
 	query := bson.M{fieldAgreementName: agreement.Name}
 	update := bson.M{"$set": agreement}
-	_, err := mongoClient.DB(database).C(collectionAgreement).Upsert(query, update)
+	_, err := db.C(collectionAgreement).Upsert(query, update)
 	if err != nil && !mgo.IsDup(err) {
 		fmt.Println(err)
 		return err
@@ -153,38 +560,99 @@ func calculateIsCompleted(agreement Agreement) bool {
 	return true
 }
 
+// MongoMigrateResultStatuses moves the results with an empty or legacy status into the result lifecycle
+func MongoMigrateResultStatuses(db *mgo.Database) {
+	var results []Result
+	err := db.C(collectionResult).
+		Find(bson.M{fieldResultStatus: bson.M{"$nin": resultStatuses}}).
+		Select(bson.M{fieldResultMethodName: 1, fieldResultStartedAt: 1, fieldResultStatus: 1}).
+		All(&results)
+	panicError(err)
+
+	now := time.Now()
+	for _, result := range results {
+		status := migratedResultStatus(result.Status)
+		transition := ResultTransition{Status: status, At: now, Reason: fmt.Sprintf("migrated from status %q", result.Status)}
+		err = db.C(collectionResult).Update(
+			bson.M{fieldResultMethodName: result.Method, fieldResultStartedAt: result.StartedAt, fieldResultStatus: bson.M{"$nin": resultStatuses}},
+			bson.M{
+				"$set":  bson.M{fieldResultStatus: status, fieldResultStatusChangedAt: now},
+				"$push": bson.M{"status_history": transition},
+			},
+		)
+		if err != mgo.ErrNotFound {
+			panicError(err)
+		}
+	}
+	if len(results) > 0 {
+		fmt.Printf("Migrated the status of %d results into the result lifecycle\n", len(results))
+	}
+}
+
 // MongoInsertDataset returns ok if the dataset was inserted or already existed
-func MongoInsertDataset(mongoClient *mgo.Session, dataset Dataset) error {
+func MongoInsertDataset(db *mgo.Database, dataset Dataset) error {
 	query := bson.M{fieldDatasetName: dataset.Name}
-	update := bson.M{"$set": dataset}
-	_, err := mongoClient.DB(database).C(collectionDataset).Upsert(query, update)
+	update := bson.M{"$set": dataset, "$inc": bson.M{fieldDatasetVersion: 1}}
+	_, err := db.C(collectionDataset).Upsert(query, update)
 
 	return handleErrorInsert(err)
 }
 
 // MongoInsertResult returns ok if the result was inserted or already existed
-func MongoInsertResult(mongoClient *mgo.Session, result Result) error {
+func MongoInsertResult(db *mgo.Database, result Result) error {
 	query := bson.M{fieldResultMethodName: result.Method, fieldResultStartedAt: result.StartedAt}
 	update := bson.M{"$set": result}
-	_, err := mongoClient.DB(database).C(collectionResult).Upsert(query, update)
+	_, err := db.C(collectionResult).Upsert(query, update)
 
 	return handleErrorInsert(err)
 }
 
-// MongoDeleteAnnotation return err if there was an error
-func MongoDeleteAnnotation(mongoClient *mgo.Session, annotation string) error {
-	_, err := mongoClient.
-		DB(database).
-		C(collectionAnnotation).
-		RemoveAll(bson.M{fieldAnnotationName: annotation})
+// MongoReplaceResult stores a result posted again by its method. The update is guarded by the stored status and status
+// history, so it does not revert a concurrent transition, it returns mgo.ErrNotFound if the result changed since it
+// was read. The tags, notes and pinned flag are set by researchers and kept, the name as well if none is posted.
+func MongoReplaceResult(db *mgo.Database, result Result, stored Result) error {
+	var update bson.M
+	if err := convertBSON(result, &update); err != nil {
+		return err
+	}
+	delete(update, "tags")
+	delete(update, "notes")
+	delete(update, "pinned")
+	if result.Name == "" {
+		delete(update, "name")
+	}
+	query := bson.M{
+		fieldResultMethodName: result.Method,
+		fieldResultStartedAt:  result.StartedAt,
+		fieldResultStatus:     stored.Status,
+		fmt.Sprintf("status_history.%d", len(stored.StatusHistory)): bson.M{"$exists": false},
+	}
+	return db.C(collectionResult).Update(query, bson.M{"$set": update})
+}
 
-	return err
+// MongoDeleteAnnotation return err if there was an error
+func MongoDeleteAnnotation(db *mgo.Database, annotation string) error {
+	// annotations are removed one by one to count exactly the removed versions
+	for {
+		var previous Annotation
+		_, err := db.
+			C(collectionAnnotation).
+			Find(bson.M{fieldAnnotationName: annotation}).
+			Select(annotationCodesFields).
+			Apply(mgo.Change{Remove: true}, &previous)
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mongoUpdateRecommendationStatistics(db, diffRecommendationCounts(recommendationCounts(previous), nil))
+	}
 }
 
 // MongoDeleteAgreement return err if there was an error
-func MongoDeleteAgreement(mongoClient *mgo.Session, agreement string) error {
-	_, err := mongoClient.
-		DB(database).
+func MongoDeleteAgreement(db *mgo.Database, agreement string) error {
+	_, err := db.
 		C(collectionAgreement).
 		RemoveAll(bson.M{fieldAgreementName: agreement})
 
@@ -192,192 +660,732 @@ func MongoDeleteAgreement(mongoClient *mgo.Session, agreement string) error {
 }
 
 // MongoDeleteDataset return ok if db entry could be deleted
-func MongoDeleteDataset(mongoClient *mgo.Session, dataset string) bool {
-	_, err := mongoClient.
-		DB(database).
+func MongoDeleteDataset(db *mgo.Database, dataset string) bool {
+	_, err := db.
 		C(collectionDataset).
 		RemoveAll(bson.M{fieldDatasetName: dataset})
+	if err == nil {
+		_, err = db.C(collectionGroundTruth).RemoveAll(bson.M{"dataset": dataset})
+	}
 
 	return err == nil
 }
 
-func MongoPostAllTORE(mongoClient *mgo.Session, tores []string) error {
-	query := bson.M{fieldToreTypes: fieldToreTypes}
-	update := bson.M{"$set": bson.M{fieldToreTypes: fieldToreTypes, "names": tores}}
-	_, err := mongoClient.DB(database).C(collectionTores).Upsert(query, update)
+// MongoGetGroundTruths returns the label sets of a dataset
+func MongoGetGroundTruths(db *mgo.Database, dataset string) []GroundTruth {
+	groundTruths := []GroundTruth{}
+	err := db.
+		C(collectionGroundTruth).
+		Find(bson.M{"dataset": dataset}).
+		Sort("name").
+		All(&groundTruths)
+	panicError(err)
 
-	return err
+	return groundTruths
 }
 
-func MongoGetAllTORE(mongoClient *mgo.Session) []string {
-	names := bson.M{"names": new([]string)}
-	err := mongoClient.
-		DB(database).
-		C(collectionTores).Find(bson.M{fieldToreTypes: fieldToreTypes}).One(&names)
+// MongoGetGroundTruth returns a label set of a dataset or mgo.ErrNotFound
+func MongoGetGroundTruth(db *mgo.Database, dataset string, name string) (GroundTruth, error) {
+	var groundTruth GroundTruth
+	err := db.
+		C(collectionGroundTruth).
+		Find(bson.M{"dataset": dataset, "name": name}).
+		One(&groundTruth)
 
-	var retnames []string
-	if err != nil {
-		fmt.Printf("Error getting tore types: %v\n", err)
-		return retnames
-	}
-	for _, value := range names["names"].([]interface{}) {
-		retnames = append(retnames, value.(string))
-	}
-	return retnames
+	return groundTruth, err
 }
 
-func MongoPostAllRelationshipNames(mongoClient *mgo.Session, names []string, owners []string) error {
-	query := bson.M{fieldRelationshipNames: fieldRelationshipNames}
-	update := bson.M{"$set": bson.M{fieldRelationshipNames: fieldRelationshipNames, "names": names, "owners": owners}}
-	_, err := mongoClient.DB(database).C(collectionRelationships).Upsert(query, update)
+// MongoSaveGroundTruth creates or replaces a label set
+func MongoSaveGroundTruth(db *mgo.Database, groundTruth GroundTruth) error {
+	groundTruth.LastUpdated = time.Now()
+	if groundTruth.Elements == nil {
+		groundTruth.Elements = []TruthElement{}
+	}
+	_, err := db.C(collectionGroundTruth).Upsert(
+		bson.M{"dataset": groundTruth.Dataset, "name": groundTruth.Name},
+		groundTruth,
+	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return mongoMirrorDefaultGroundTruth(db, groundTruth.Dataset, groundTruth.Name)
 }
 
-func MongoGetAllRelationshipNames(mongoClient *mgo.Session) ([]string, []string) {
-	names := bson.M{"names": new([]string), "owners": new([]string)}
-	err := mongoClient.
-		DB(database).
-		C(collectionRelationships).Find(bson.M{fieldRelationshipNames: fieldRelationshipNames}).One(&names)
-
-	var retnames []string
-	var retOwners []string
+// MongoDeleteGroundTruth removes a label set or returns mgo.ErrNotFound
+func MongoDeleteGroundTruth(db *mgo.Database, dataset string, name string) error {
+	err := db.C(collectionGroundTruth).Remove(bson.M{"dataset": dataset, "name": name})
 	if err != nil {
-		fmt.Printf("Error getting relationship names: %v\n", err)
-		return retnames, retOwners
+		return err
 	}
-	var owners = names["owners"].([]interface{})
 
-	for index, value := range names["names"].([]interface{}) {
-		retnames = append(retnames, value.(string))
-		retOwners = append(retOwners, owners[index].(string))
-	}
-	return retnames, retOwners
+	return mongoMirrorDefaultGroundTruth(db, dataset, name)
 }
 
-// MongoGetAnnotation returns an Annotation
-func MongoGetAnnotation(mongoClient *mgo.Session, annotation string) Annotation {
-	var annotationObj []Annotation
-	err := mongoClient.
-		DB(database).
-		C(collectionAnnotation).
-		Find(bson.M{fieldAnnotationName: annotation}).
-		All(&annotationObj)
+// MongoSetTruthElement adds the element to the label set or replaces the value of the document, it returns
+// mgo.ErrNotFound if the label set does not exist
+func MongoSetTruthElement(db *mgo.Database, dataset string, name string, element TruthElement) error {
+	collection := db.C(collectionGroundTruth)
+	now := time.Now()
+	err := collection.Update(
+		bson.M{"dataset": dataset, "name": name, "elements.id": element.Id},
+		bson.M{"$set": bson.M{"elements.$.value": element.Value, "last_updated": now}},
+	)
+	if err == mgo.ErrNotFound {
+		// the document has no value yet, only push if it still has none
+		err = collection.Update(
+			bson.M{"dataset": dataset, "name": name, "elements.id": bson.M{"$ne": element.Id}},
+			bson.M{"$push": bson.M{"elements": element}, "$set": bson.M{"last_updated": now}},
+		)
+	}
 	if err != nil {
-		fmt.Println("ERR", err)
-		panic(err)
+		return err
 	}
 
-	return annotationObj[0]
+	return mongoMirrorDefaultGroundTruth(db, dataset, name)
 }
 
-// MongoGetAgreement returns an Agreement
-func MongoGetAgreement(mongoClient *mgo.Session, agreement string) Agreement {
-	var agreementObj []Agreement
-	err := mongoClient.
-		DB(database).
-		C(collectionAgreement).
-		Find(bson.M{fieldAgreementName: agreement}).
-		All(&agreementObj)
+// MongoRemoveTruthElement removes the value of a document from the label set, it returns mgo.ErrNotFound if the
+// label set does not exist or has no value for the document
+func MongoRemoveTruthElement(db *mgo.Database, dataset string, name string, id string) error {
+	err := db.C(collectionGroundTruth).Update(
+		bson.M{"dataset": dataset, "name": name, "elements.id": id},
+		bson.M{"$pull": bson.M{"elements": bson.M{"id": id}}, "$set": bson.M{"last_updated": time.Now()}},
+	)
 	if err != nil {
-		fmt.Println("ERR", err)
-		panic(err)
+		return err
 	}
 
-	return agreementObj[0]
+	return mongoMirrorDefaultGroundTruth(db, dataset, name)
 }
 
-// MongoGetAnnotationsForDataset returns a list of Annotations for a dataset
-func MongoGetAnnotationsForDataset(mongoClient *mgo.Session, dataset string) []Annotation {
-	var annotations []Annotation
-	err := mongoClient.
-		DB(database).
-		C(collectionAnnotation).
-		Find(bson.M{fieldDatasetName: dataset}).
-		All(&annotations)
-	if err != nil {
-		fmt.Println("ERR", err)
-		panic(err)
+// mongoMirrorDefaultGroundTruth copies the default label set into the ground truth of the dataset
+func mongoMirrorDefaultGroundTruth(db *mgo.Database, dataset string, name string) error {
+	if name != defaultGroundTruth {
+		return nil
+	}
+	elements := []TruthElement{}
+	groundTruth, err := MongoGetGroundTruth(db, dataset, name)
+	if err == nil {
+		elements = groundTruth.Elements
+	} else if err != mgo.ErrNotFound {
+		return err
 	}
 
-	return annotations
+	err = db.C(collectionDataset).Update(bson.M{fieldDatasetName: dataset}, bson.M{"$set": bson.M{"ground_truth": elements}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
 }
 
-// MongoDeleteResult return ok if db entry could be deleted
-func MongoDeleteResult(mongoClient *mgo.Session, result time.Time) bool {
-	_, err := mongoClient.
-		DB(database).
-		C(collectionResult).
-		RemoveAll(bson.M{fieldResultStartedAt: result})
+// MongoPostAllTORE activates the given tore categories of a scheme, creating missing ones, and deprecates all others
+func MongoPostAllTORE(db *mgo.Database, scheme string, tores []string) error {
+	if tores == nil {
+		tores = []string{}
+	}
+	collection := db.C(collectionToreCategories)
+	for _, name := range tores {
+		query := bson.M{fieldScheme: scheme, fieldToreCategoryName: name}
+		update := bson.M{
+			"$set":         bson.M{fieldToreCategoryState: toreCategoryActive, "last_updated": time.Now()},
+			"$setOnInsert": bson.M{"level": defaultToreLevels[name], "description": "", "color": ""},
+		}
+		_, err := collection.Upsert(query, update)
+		if err != nil {
+			return err
+		}
+	}
 
-	return err == nil
+	_, err := collection.UpdateAll(
+		bson.M{fieldScheme: scheme, fieldToreCategoryName: bson.M{"$nin": tores}, fieldToreCategoryState: toreCategoryActive},
+		bson.M{"$set": bson.M{fieldToreCategoryState: toreCategoryDeprecated, "last_updated": time.Now()}},
+	)
+	return err
 }
 
-// MongoGetDataset returns a dataset
-func MongoGetDataset(mongoClient *mgo.Session, datasetName string) Dataset {
-	var dataset []Dataset
-	err := mongoClient.
-		DB(database).
-		C(collectionDataset).
-		Find(bson.M{fieldDatasetName: datasetName}).
-		All(&dataset)
-	panicError(err)
-
-	// Return empty dataset if not found
-	if len(dataset) == 0 {
-		d := Dataset{}
-		return d
-	} else {
-		return dataset[0]
+// MongoGetAllTORE returns the names of all active tore categories of a scheme sorted by name
+func MongoGetAllTORE(db *mgo.Database, scheme string) ([]string, error) {
+	retnames := []string{}
+	var categories []ToreCategory
+	err := db.
+		C(collectionToreCategories).
+		Find(bson.M{fieldScheme: scheme, fieldToreCategoryState: toreCategoryActive}).
+		Select(bson.M{fieldToreCategoryName: 1}).
+		Sort(fieldToreCategoryName).
+		All(&categories)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		retnames = append(retnames, category.Name)
 	}
+	return retnames, nil
 }
 
-// MongoGetResult returns a dataset
-func MongoGetResult(mongoClient *mgo.Session, startedAt time.Time) Result {
-	var result []Result
-	err := mongoClient.
-		DB(database).
-		C(collectionResult).
-		Find(bson.M{fieldResultStartedAt: startedAt}).
-		All(&result)
+// MongoGetToreCategories returns all tore categories of a scheme
+func MongoGetToreCategories(db *mgo.Database, scheme string) []ToreCategory {
+	categories := []ToreCategory{}
+	err := db.
+		C(collectionToreCategories).
+		Find(bson.M{fieldScheme: scheme}).
+		Sort("level", fieldToreCategoryName).
+		All(&categories)
 	panicError(err)
 
-	// Return empty result if not found
-	if len(result) == 0 {
-		r := Result{}
-		return r
-	} else {
-		return result[0]
-	}
+	return categories
 }
 
-// MongoGetAllAnnotations get all annotations
-func MongoGetAllAnnotations(mongoClient *mgo.Session) []Annotation {
+// MongoGetToreCategory returns the tore category of a scheme with the given name or mgo.ErrNotFound
+func MongoGetToreCategory(db *mgo.Database, scheme string, name string) (ToreCategory, error) {
+	var category ToreCategory
+	err := db.
+		C(collectionToreCategories).
+		Find(bson.M{fieldScheme: scheme, fieldToreCategoryName: name}).
+		One(&category)
 
-	var annotations []Annotation
+	return category, err
+}
+
+// MongoInsertToreCategory creates or updates a tore category
+func MongoInsertToreCategory(db *mgo.Database, category ToreCategory) error {
+	category.LastUpdated = time.Now()
+	query := bson.M{fieldScheme: category.Scheme, fieldToreCategoryName: category.Name}
+	update := bson.M{"$set": category}
+	_, err := db.C(collectionToreCategories).Upsert(query, update)
 
-	err := mongoClient.
-		DB(database).
-		C(collectionAnnotation).Find(bson.M{}).Select(bson.M{"uploaded_at": 1, "last_updated": 1, "name": 1, "dataset": 1, "sentence_tokenization_enabled_for_annotation": 1}).All(&annotations)
+	return err
+}
 
+// MongoRenameToreCategory stores the category under its new name and rewrites all codes of its scheme using the old
+// name. Results and recommendations are not bound to a scheme and are only rewritten for the default scheme.
+func MongoRenameToreCategory(db *mgo.Database, oldName string, category ToreCategory) error {
+	category.LastUpdated = time.Now()
+	err := db.C(collectionToreCategories).Update(bson.M{fieldScheme: category.Scheme, fieldToreCategoryName: oldName}, bson.M{"$set": category})
 	if err != nil {
-		fmt.Println("ERR", err)
-		panic(err)
+		return err
+	}
+	if oldName == category.Name {
+		return nil
 	}
 
-	fmt.Printf("getAllAnnotations result: %v\n", annotations)
-
-	return annotations
+	// the positional operator only updates the first matching array element, so repeat until nothing changes
+	renames := []struct {
+		collection      string
+		field           string
+		positionalField string
+		byScheme        bool
+	}{
+		{collectionAnnotation, "codes.tore", "codes.$.tore", true},
+		{collectionAnnotation, "tores", "tores.$", true},
+		{collectionAgreement, "code_alternatives.code.tore", "code_alternatives.$.code.tore", true},
+		{collectionResult, "codes.tore", "codes.$.tore", false},
+		{collectionRecommendation, "torecodes", "torecodes.$", false},
+	}
+	for _, rename := range renames {
+		query := bson.M{rename.field: oldName}
+		if rename.byScheme {
+			query[fieldScheme] = category.Scheme
+		} else if category.Scheme != defaultScheme {
+			continue
+		}
+		for {
+			info, err := db.C(rename.collection).UpdateAll(query, bson.M{"$set": bson.M{rename.positionalField: category.Name}})
+			if err != nil {
+				return err
+			}
+			if info.Updated == 0 {
+				break
+			}
+		}
+	}
+
+	if _, err := MongoRebuildRecommendationStatistics(db); err != nil {
+		fmt.Printf("Error rebuilding recommendation statistics: %v\n", err)
+	}
+	return nil
+}
+
+// MongoCountToreCategoryUsage returns the number of annotations and agreements of a scheme with codes of the given tore category
+func MongoCountToreCategoryUsage(db *mgo.Database, scheme string, name string) (int, error) {
+	annotations, err := db.C(collectionAnnotation).Find(bson.M{fieldScheme: scheme, "codes.tore": name}).Count()
+	if err != nil {
+		return 0, err
+	}
+	agreements, err := db.C(collectionAgreement).Find(bson.M{fieldScheme: scheme, "code_alternatives.code.tore": name}).Count()
+	if err != nil {
+		return 0, err
+	}
+	return annotations + agreements, nil
+}
+
+// MongoDeleteToreCategory return err if there was an error
+func MongoDeleteToreCategory(db *mgo.Database, scheme string, name string) error {
+	return db.
+		C(collectionToreCategories).
+		Remove(bson.M{fieldScheme: scheme, fieldToreCategoryName: name})
+}
+
+// MongoPostAllRelationshipNames creates or updates the relationship types of a scheme with the given owners and removes all
+// others, callers check that the removed types are not used by annotations
+func MongoPostAllRelationshipNames(db *mgo.Database, scheme string, names []string, owners []string) error {
+	if names == nil {
+		names = []string{}
+	}
+	collection := db.C(collectionRelationshipTypes)
+	for index, name := range names {
+		query := bson.M{fieldScheme: scheme, fieldRelationshipTypeName: name}
+		update := bson.M{
+			"$set":         bson.M{"owner": owners[index], "last_updated": time.Now()},
+			"$setOnInsert": bson.M{"description": "", "source_tores": []string{}, "target_tores": []string{}},
+		}
+		_, err := collection.Upsert(query, update)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := collection.RemoveAll(bson.M{fieldScheme: scheme, fieldRelationshipTypeName: bson.M{"$nin": names}})
+	return err
+}
+
+// MongoGetAllRelationshipNames returns the names and owners of all relationship types of a scheme
+func MongoGetAllRelationshipNames(db *mgo.Database, scheme string) ([]string, []string, error) {
+	retnames := []string{}
+	retOwners := []string{}
+	var relationshipTypes []RelationshipType
+	err := db.
+		C(collectionRelationshipTypes).
+		Find(bson.M{fieldScheme: scheme}).
+		Select(bson.M{fieldRelationshipTypeName: 1, "owner": 1}).
+		Sort(fieldRelationshipTypeName).
+		All(&relationshipTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, relationshipType := range relationshipTypes {
+		retnames = append(retnames, relationshipType.Name)
+		retOwners = append(retOwners, relationshipType.Owner)
+	}
+	return retnames, retOwners, nil
+}
+
+// MongoGetRelationshipTypes returns all relationship types of a scheme
+func MongoGetRelationshipTypes(db *mgo.Database, scheme string) []RelationshipType {
+	relationshipTypes := []RelationshipType{}
+	err := db.
+		C(collectionRelationshipTypes).
+		Find(bson.M{fieldScheme: scheme}).
+		Sort(fieldRelationshipTypeName).
+		All(&relationshipTypes)
+	panicError(err)
+
+	return relationshipTypes
+}
+
+// MongoInsertRelationshipType creates or updates a relationship type
+func MongoInsertRelationshipType(db *mgo.Database, relationshipType RelationshipType) error {
+	relationshipType.LastUpdated = time.Now()
+	query := bson.M{fieldScheme: relationshipType.Scheme, fieldRelationshipTypeName: relationshipType.Name}
+	update := bson.M{"$set": relationshipType}
+	_, err := db.C(collectionRelationshipTypes).Upsert(query, update)
+
+	return err
+}
+
+// MongoCountRelationshipTypeUsage returns the number of annotations of a scheme with relationships of the given type
+func MongoCountRelationshipTypeUsage(db *mgo.Database, scheme string, name string) (int, error) {
+	return db.C(collectionAnnotation).Find(bson.M{fieldScheme: scheme, "tore_relationships.relationship_name": name}).Count()
+}
+
+// MongoDeleteRelationshipType return err if there was an error
+func MongoDeleteRelationshipType(db *mgo.Database, scheme string, name string) error {
+	return db.
+		C(collectionRelationshipTypes).
+		Remove(bson.M{fieldScheme: scheme, fieldRelationshipTypeName: name})
+}
+
+// MongoGetAnnotationSchemes returns all annotation schemes
+func MongoGetAnnotationSchemes(db *mgo.Database) []AnnotationScheme {
+	schemes := []AnnotationScheme{}
+	err := db.
+		C(collectionAnnotationSchemes).
+		Find(bson.M{}).
+		Sort(fieldSchemeName).
+		All(&schemes)
+	panicError(err)
+
+	return schemes
+}
+
+// MongoAnnotationSchemeExists returns true if the scheme exists, the default scheme always exists
+func MongoAnnotationSchemeExists(db *mgo.Database, scheme string) bool {
+	if scheme == defaultScheme {
+		return true
+	}
+	count, err := db.C(collectionAnnotationSchemes).Find(bson.M{fieldSchemeName: scheme}).Count()
+	panicError(err)
+
+	return count > 0
+}
+
+// MongoInsertAnnotationScheme creates or updates an annotation scheme
+func MongoInsertAnnotationScheme(db *mgo.Database, scheme AnnotationScheme) error {
+	query := bson.M{fieldSchemeName: scheme.Name}
+	update := bson.M{"$set": bson.M{"description": scheme.Description}, "$setOnInsert": bson.M{"created_at": time.Now()}}
+	_, err := db.C(collectionAnnotationSchemes).Upsert(query, update)
+
+	return err
+}
+
+// MongoCountAnnotationSchemeUsage returns the number of annotations and agreements bound to the scheme
+func MongoCountAnnotationSchemeUsage(db *mgo.Database, scheme string) (int, error) {
+	annotations, err := db.C(collectionAnnotation).Find(bson.M{fieldScheme: scheme}).Count()
+	if err != nil {
+		return 0, err
+	}
+	agreements, err := db.C(collectionAgreement).Find(bson.M{fieldScheme: scheme}).Count()
+	if err != nil {
+		return 0, err
+	}
+	return annotations + agreements, nil
+}
+
+// MongoDeleteAnnotationScheme deletes the scheme with all its tore categories and relationship types
+func MongoDeleteAnnotationScheme(db *mgo.Database, scheme string) error {
+	err := db.C(collectionAnnotationSchemes).Remove(bson.M{fieldSchemeName: scheme})
+	if err != nil {
+		return err
+	}
+	_, err = db.C(collectionToreCategories).RemoveAll(bson.M{fieldScheme: scheme})
+	if err != nil {
+		return err
+	}
+	_, err = db.C(collectionRelationshipTypes).RemoveAll(bson.M{fieldScheme: scheme})
+	return err
+}
+
+// MongoGetAnnotation returns an Annotation
+func MongoGetAnnotation(db *mgo.Database, annotation string) Annotation {
+	var annotationObj []Annotation
+	err := db.
+		C(collectionAnnotation).
+		Find(bson.M{fieldAnnotationName: annotation}).
+		All(&annotationObj)
+	if err != nil {
+		fmt.Println("ERR", err)
+		panic(err)
+	}
+
+	return annotationObj[0]
+}
+
+// MongoFindAnnotation returns the annotation with the given name and whether it exists
+func MongoFindAnnotation(db *mgo.Database, annotation string) (Annotation, bool) {
+	var annotationObj Annotation
+	err := db.
+		C(collectionAnnotation).
+		Find(bson.M{fieldAnnotationName: annotation}).
+		One(&annotationObj)
+	if err == mgo.ErrNotFound {
+		return annotationObj, false
+	}
+	panicError(err)
+
+	return annotationObj, true
+}
+
+// MongoGetDatasetTokens returns the documents and tokens of the oldest tokenized annotation of the dataset, so that
+// new annotations share its token indices
+func MongoGetDatasetTokens(db *mgo.Database, dataset string) (Annotation, bool) {
+	var annotation Annotation
+	err := db.
+		C(collectionAnnotation).
+		Find(bson.M{"dataset": dataset, "tokens.0": bson.M{"$exists": true}}).
+		Sort("uploaded_at").
+		Select(bson.M{"docs": 1, "tokens": 1}).
+		One(&annotation)
+	if err == mgo.ErrNotFound {
+		return annotation, false
+	}
+	panicError(err)
+
+	return annotation, true
+}
+
+// MongoGetAgreement returns an Agreement
+func MongoGetAgreement(db *mgo.Database, agreement string) Agreement {
+	var agreementObj []Agreement
+	err := db.
+		C(collectionAgreement).
+		Find(bson.M{fieldAgreementName: agreement}).
+		All(&agreementObj)
+	if err != nil {
+		fmt.Println("ERR", err)
+		panic(err)
+	}
+
+	return agreementObj[0]
+}
+
+// MongoFindAgreement returns the agreement with the given name and whether it exists
+func MongoFindAgreement(db *mgo.Database, agreement string) (Agreement, bool) {
+	var agreementObj Agreement
+	err := db.
+		C(collectionAgreement).
+		Find(bson.M{fieldAgreementName: agreement}).
+		One(&agreementObj)
+	if err == mgo.ErrNotFound {
+		return agreementObj, false
+	}
+	panicError(err)
+
+	return agreementObj, true
+}
+
+// MongoGetAnnotationsForDataset returns a list of Annotations for a dataset
+func MongoGetAnnotationsForDataset(db *mgo.Database, dataset string) []Annotation {
+	var annotations []Annotation
+	err := db.
+		C(collectionAnnotation).
+		Find(bson.M{fieldDatasetName: dataset}).
+		All(&annotations)
+	if err != nil {
+		fmt.Println("ERR", err)
+		panic(err)
+	}
+
+	return annotations
+}
+
+// MongoDeleteResult return ok if db entry could be deleted
+func MongoDeleteResult(db *mgo.Database, result time.Time) bool {
+	_, err := db.
+		C(collectionResult).
+		RemoveAll(bson.M{fieldResultStartedAt: result})
+
+	return err == nil
+}
+
+// maxIngestAttempts bounds the retries of an ingest that raced with another write to the same dataset
+const maxIngestAttempts = 5
+
+// MongoIngestDocuments appends the documents that are not in the dataset yet and creates the dataset if it does not
+// exist. Every write of the documents increments the version of the dataset, the new documents are only pushed if
+// the version did not change since the dataset was read, otherwise the merge is retried.
+func MongoIngestDocuments(db *mgo.Database, datasetName string, ingest DatasetIngest) (DatasetIngestRecord, error) {
+	record := DatasetIngestRecord{
+		Id:           bson.NewObjectId(),
+		Dataset:      datasetName,
+		Received:     len(ingest.Documents),
+		CrawlerJobId: ingest.CrawlerJobId,
+		CrawlerRunId: ingest.CrawlerRunId,
+	}
+	collection := db.C(collectionDataset)
+
+	for attempt := 0; attempt < maxIngestAttempts; attempt++ {
+		record.IngestedAt = time.Now()
+		var dataset struct {
+			Dataset `bson:",inline"`
+			Version int `bson:"version"`
+		}
+		err := collection.Find(bson.M{fieldDatasetName: datasetName}).One(&dataset)
+		if err == mgo.ErrNotFound {
+			added, duplicates := mergeDocuments(nil, ingest.Documents)
+			dataset.Dataset = Dataset{Name: datasetName, UploadedAt: record.IngestedAt, Size: len(added), Documents: added}
+			dataset.Version = 1
+			err = collection.Insert(dataset)
+			if mgo.IsDup(err) {
+				continue
+			} else if err != nil {
+				return record, err
+			}
+			record.Added, record.Duplicates, record.Size, record.Created = len(added), duplicates, len(added), true
+			record.DatasetVersion = dataset.Version
+			return record, mongoInsertIngestRecord(db, record)
+		} else if err != nil {
+			return record, err
+		}
+
+		added, duplicates := mergeDocuments(dataset.Documents, ingest.Documents)
+		size := len(dataset.Documents) + len(added)
+		query := bson.M{fieldDatasetName: datasetName, fieldDatasetVersion: dataset.Version}
+		if dataset.Version == 0 {
+			query[fieldDatasetVersion] = bson.M{"$in": []interface{}{nil, 0}}
+		}
+		update := bson.M{
+			"$push": bson.M{"documents": bson.M{"$each": added}},
+			"$set":  bson.M{"size": size},
+			"$inc":  bson.M{fieldDatasetVersion: 1},
+		}
+		if len(dataset.Documents) == 0 {
+			// legacy datasets may store no documents array to push to
+			update = bson.M{"$set": bson.M{"documents": added, "size": size}, "$inc": bson.M{fieldDatasetVersion: 1}}
+		}
+		err = collection.Update(query, update)
+		if err == mgo.ErrNotFound {
+			continue
+		} else if err != nil {
+			return record, err
+		}
+		record.Added, record.Duplicates, record.Size = len(added), duplicates, size
+		record.DatasetVersion = dataset.Version + 1
+		return record, mongoInsertIngestRecord(db, record)
+	}
+	return record, fmt.Errorf("dataset %s was changed concurrently %d times", datasetName, maxIngestAttempts)
+}
+
+// mongoInsertIngestRecord stores the ingest and marks the dataset as written by the crawler job run of the ingest
+func mongoInsertIngestRecord(db *mgo.Database, record DatasetIngestRecord) error {
+	err := db.C(collectionDatasetIngests).Insert(record)
+	if err != nil || record.CrawlerRunId == "" {
+		return err
+	}
+	return db.C(collectionCrawlerJobRuns).UpdateId(
+		record.CrawlerRunId,
+		bson.M{"$set": bson.M{"dataset_name": record.Dataset, "ingested_at": record.IngestedAt}},
+	)
+}
+
+// MongoGetDatasetIngests returns the ingests of a dataset, the latest first
+func MongoGetDatasetIngests(db *mgo.Database, datasetName string) []DatasetIngestRecord {
+	ingests := []DatasetIngestRecord{}
+	err := db.
+		C(collectionDatasetIngests).
+		Find(bson.M{"dataset": datasetName}).
+		Sort("-ingested_at").
+		All(&ingests)
+	panicError(err)
+
+	return ingests
+}
+
+// MongoDatasetExists returns whether a dataset with the name exists
+func MongoDatasetExists(db *mgo.Database, datasetName string) bool {
+	count, err := db.C(collectionDataset).Find(bson.M{fieldDatasetName: datasetName}).Count()
+	panicError(err)
+
+	return count > 0
+}
+
+// MongoGetDataset returns a dataset
+func MongoGetDataset(db *mgo.Database, datasetName string) Dataset {
+	var dataset []Dataset
+	err := db.
+		C(collectionDataset).
+		Find(bson.M{fieldDatasetName: datasetName}).
+		All(&dataset)
+	panicError(err)
+
+	// Return empty dataset if not found
+	if len(dataset) == 0 {
+		d := Dataset{}
+		return d
+	} else {
+		return dataset[0]
+	}
+}
+
+// MongoGetResult returns a dataset
+func MongoGetResult(db *mgo.Database, startedAt time.Time) Result {
+	var result []Result
+	err := db.
+		C(collectionResult).
+		Find(bson.M{fieldResultStartedAt: startedAt}).
+		All(&result)
+	panicError(err)
+
+	// Return empty result if not found
+	if len(result) == 0 {
+		r := Result{}
+		return r
+	} else {
+		return result[0]
+	}
+}
+
+// MongoTransitionResult moves a result to the status of the transition, it returns mgo.ErrNotFound if there is no
+// result started at the time with the previous status
+func MongoTransitionResult(db *mgo.Database, startedAt time.Time, previousStatus string, transition ResultTransition) error {
+	return db.C(collectionResult).Update(
+		bson.M{fieldResultStartedAt: startedAt, fieldResultStatus: previousStatus},
+		bson.M{
+			"$set":  bson.M{fieldResultStatus: transition.Status, fieldResultStatusChangedAt: transition.At},
+			"$push": bson.M{"status_history": transition},
+		},
+	)
+}
+
+// MongoFailStuckResults marks the results failed that are running for longer than the timeout, results without a
+// status history are running since they started
+func MongoFailStuckResults(db *mgo.Database, timeout time.Duration, now time.Time) (int, error) {
+	cutoff := now.Add(-timeout)
+	transition := ResultTransition{Status: resultFailed, At: now, Reason: fmt.Sprintf("running for more than %s", timeout)}
+	info, err := db.C(collectionResult).UpdateAll(
+		bson.M{
+			fieldResultStatus: resultRunning,
+			"$or": []bson.M{
+				{fieldResultStatusChangedAt: bson.M{"$lt": cutoff}},
+				{fieldResultStatusChangedAt: bson.M{"$exists": false}, fieldResultStartedAt: bson.M{"$lt": cutoff}},
+			},
+		},
+		bson.M{
+			"$set":  bson.M{fieldResultStatus: resultFailed, fieldResultStatusChangedAt: now},
+			"$push": bson.M{"status_history": transition},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return info.Updated, nil
+}
+
+// MongoSetResultEvaluation stores the evaluation in the metrics of a result and removes the scores of a previous
+// evaluation that were not computed again, it returns mgo.ErrNotFound if there is no result started at the time
+func MongoSetResultEvaluation(db *mgo.Database, startedAt time.Time, evaluation ResultEvaluation) error {
+	collection := db.C(collectionResult)
+	// the fields of the evaluation can only be set inside a metrics document
+	err := collection.Update(
+		bson.M{fieldResultStartedAt: startedAt, "metrics": bson.M{"$not": bson.M{"$type": 3}}},
+		bson.M{"$set": bson.M{"metrics": bson.M{}}},
+	)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+
+	set, unset := evaluation.metricUpdate()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return collection.Update(bson.M{fieldResultStartedAt: startedAt}, update)
+}
+
+// MongoGetAllAnnotations get all annotations
+func MongoGetAllAnnotations(db *mgo.Database) []Annotation {
+
+	var annotations []Annotation
+
+	err := db.
+		C(collectionAnnotation).Find(bson.M{}).Select(bson.M{"uploaded_at": 1, "last_updated": 1, "name": 1, "dataset": 1, "scheme": 1, "sentence_tokenization_enabled_for_annotation": 1}).All(&annotations)
+
+	if err != nil {
+		fmt.Println("ERR", err)
+		panic(err)
+	}
+
+	fmt.Printf("getAllAnnotations result: %v\n", annotations)
+
+	return annotations
 }
 
 // MongoGetAllAgreements get all agreements
-func MongoGetAllAgreements(mongoClient *mgo.Session) []Agreement {
+func MongoGetAllAgreements(db *mgo.Database) []Agreement {
 
 	var agreements []Agreement
 
-	err := mongoClient.
-		DB(database).
-		C(collectionAgreement).Find(bson.M{}).Select(bson.M{"created_at": 1, "last_updated": 1, "name": 1, "dataset": 1, "annotation_names": 1, "sentence_tokenization_enabled_for_agreement": 1, "is_completed": 1}).All(&agreements)
+	err := db.
+		C(collectionAgreement).Find(bson.M{}).Select(bson.M{"created_at": 1, "last_updated": 1, "name": 1, "dataset": 1, "scheme": 1, "annotation_names": 1, "sentence_tokenization_enabled_for_agreement": 1, "is_completed": 1}).All(&agreements)
 
 	if err != nil {
 		fmt.Println("ERR", err)
@@ -390,12 +1398,11 @@ func MongoGetAllAgreements(mongoClient *mgo.Session) []Agreement {
 }
 
 // MongoGetAllDatasets returns a dataset
-func MongoGetAllDatasets(mongoClient *mgo.Session) []string {
+func MongoGetAllDatasets(db *mgo.Database) []string {
 
 	var datasetNames []string
 
-	err := mongoClient.
-		DB(database).
+	err := db.
 		C(collectionDataset).
 		Find(nil).
 		Distinct(fieldDatasetName, &datasetNames)
@@ -408,127 +1415,336 @@ func MongoGetAllDatasets(mongoClient *mgo.Session) []string {
 }
 
 // MongoGetAllResults returns all results
-func MongoGetAllResults(mongoClient *mgo.Session) []Result {
+func MongoGetAllResults(db *mgo.Database) []Result {
+	return MongoFindResults(db, nil, false)
+}
+
+// MongoFindResults returns the results having all of the tags, only pinned ones if pinnedOnly is set
+func MongoFindResults(db *mgo.Database, tags []string, pinnedOnly bool) []Result {
+	query := bson.M{}
+	if len(tags) > 0 {
+		query[fieldResultTags] = bson.M{"$all": tags}
+	}
+	if pinnedOnly {
+		query[fieldResultPinned] = true
+	}
+
 	var results []Result
-	err := mongoClient.
-		DB(database).
+	err := db.
 		C(collectionResult).
-		Find(nil).
+		Find(query).
 		All(&results)
 	panicError(err)
 
 	return results
 }
 
-// MongoGetCrawlerJobs returns all registered crawler jobs
-func MongoGetCrawlerJobs(mongoClient *mgo.Session) []CrawlerJobs {
+// MongoUpdateResult sets the fields of the patch for the result started at the time, it returns mgo.ErrNotFound if
+// there is no such result
+func MongoUpdateResult(db *mgo.Database, startedAt time.Time, patch ResultPatch) error {
+	set := patch.set()
+	if len(set) == 0 {
+		return nil
+	}
+	return db.C(collectionResult).Update(bson.M{fieldResultStartedAt: startedAt}, bson.M{"$set": set})
+}
 
-	var crawlerJobs []CrawlerJobs
+// MongoGetAnnotationsProgress returns the annotations matching the query with the fields needed for their progress
+func MongoGetAnnotationsProgress(db *mgo.Database, query bson.M) []Annotation {
+	annotations := []Annotation{}
+	err := db.
+		C(collectionAnnotation).
+		Find(query).
+		Select(bson.M{"name": 1, "dataset": 1, "last_updated": 1, "docs": 1, "codes.tokens": 1, fieldAnnotationAssignee: 1, fieldAnnotationStatus: 1, "status_changed_at": 1, fieldAnnotationDoneDocs: 1}).
+		Sort("name").
+		All(&annotations)
+	panicError(err)
 
-	err := mongoClient.
-		DB(database).
-		C(collectionCrawlerJobs).Find(bson.M{}).Select(bson.M{"subreddit_names": 1, "date": 1, "occurrence": 1, "number_posts": 1, "dataset_name": 1, "request": 1}).All(&crawlerJobs)
+	return annotations
+}
 
-	if err != nil {
-		fmt.Println("ERR", err)
-		panic(err)
+// MongoUpdateAnnotationAssignment sets the assignee and status of an annotation, the status only if it changes
+func MongoUpdateAnnotationAssignment(db *mgo.Database, name string, assignment AnnotationAssignment, now time.Time) error {
+	set := bson.M{}
+	unset := bson.M{}
+	if assignment.Assignee != nil {
+		if *assignment.Assignee == "" {
+			unset[fieldAnnotationAssignee] = ""
+		} else {
+			set[fieldAnnotationAssignee] = *assignment.Assignee
+		}
+	}
+	if assignment.Status != "" {
+		set[fieldAnnotationStatus] = assignment.Status
+		set["status_changed_at"] = now
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	return db.C(collectionAnnotation).Update(bson.M{fieldAnnotationName: name}, update)
+}
+
+// MongoSetAnnotationDocumentDone marks a document of an annotation as done or not done
+func MongoSetAnnotationDocumentDone(db *mgo.Database, name string, document string, done bool) error {
+	update := bson.M{"$pull": bson.M{fieldAnnotationDoneDocs: document}}
+	if done {
+		update = bson.M{"$addToSet": bson.M{fieldAnnotationDoneDocs: document}}
 	}
+	return db.C(collectionAnnotation).Update(bson.M{fieldAnnotationName: name}, update)
+}
 
-	fmt.Printf("getCrawlerJobs result: %v\n", crawlerJobs)
+// MongoGetAllCrawlerJobs returns the crawler jobs of a source, or of all sources if the source is empty
+func MongoGetAllCrawlerJobs(db *mgo.Database, source string) []CrawlerJob {
+	query := bson.M{}
+	if source != "" {
+		query[fieldCrawlerJobSource] = source
+	}
+	jobs := []CrawlerJob{}
+	err := db.
+		C(collectionCrawlerJob).
+		Find(query).
+		Sort(fieldCrawlerJobDate).
+		All(&jobs)
+	panicError(err)
 
-	return crawlerJobs
+	return jobs
+}
+
+// MongoGetCrawlerJob returns the crawler job with the id or mgo.ErrNotFound
+func MongoGetCrawlerJob(db *mgo.Database, id bson.ObjectId) (CrawlerJob, error) {
+	var job CrawlerJob
+	err := db.C(collectionCrawlerJob).FindId(id).One(&job)
 
+	return job, err
 }
 
-func MongoInsertCrawlerJobs(mongoClient *mgo.Session, crawlerJob CrawlerJobs) error {
-	crawlerJob.Date = time.Now()
-	//query := bson.M{fieldCrawlerJobDate: crawlerJob.Date}
-	//update := bson.M{"$set": crawlerJob}
+// MongoFindCrawlerJob returns the crawler job of a source created at the date or mgo.ErrNotFound
+func MongoFindCrawlerJob(db *mgo.Database, source string, date time.Time) (CrawlerJob, error) {
+	var job CrawlerJob
+	err := db.
+		C(collectionCrawlerJob).
+		Find(bson.M{fieldCrawlerJobSource: source, fieldCrawlerJobDate: date}).
+		One(&job)
 
-	var v interface{}
-	v = crawlerJob
-	fmt.Printf("Inserting Data: ")
-	fmt.Printf("%+v\n", v)
-	err := mongoClient.DB(database).C(collectionCrawlerJobs).Insert(v)
-	if err != nil && !mgo.IsDup(err) {
-		fmt.Println(err)
+	return job, err
+}
+
+// MongoInsertCrawlerJob stores a new crawler job created now and returns it with its id
+func MongoInsertCrawlerJob(db *mgo.Database, job CrawlerJob) (CrawlerJob, error) {
+	job.Id = bson.NewObjectId()
+	// jobs are identified by their date on the legacy routes, so it has to survive the millisecond precision of mongo
+	job.Date = time.Now().Truncate(time.Millisecond)
+	err := db.C(collectionCrawlerJob).Insert(job)
+
+	return job, err
+}
+
+// MongoDeleteCrawlerJobById removes a crawler job and its run history
+func MongoDeleteCrawlerJobById(db *mgo.Database, id bson.ObjectId) error {
+	err := db.C(collectionCrawlerJob).RemoveId(id)
+	if err != nil {
 		return err
 	}
+	_, err = db.C(collectionCrawlerJobRuns).RemoveAll(bson.M{"job_id": id})
 
-	return nil
+	return err
 }
 
-func MongoDeleteCrawlerJob(mongoClient *mgo.Session, date time.Time) error {
-	_, err := mongoClient.
-		DB(database).
-		C(collectionCrawlerJobs).
-		RemoveAll(bson.M{fieldCrawlerJobDate: date})
+// MongoStopCrawlerJob stops a crawler job from repeating
+func MongoStopCrawlerJob(db *mgo.Database, id bson.ObjectId) error {
+	update := bson.M{"$set": bson.M{"occurrence": 0}, "$unset": bson.M{fieldCrawlerJobNextRun: ""}}
+	return db.C(collectionCrawlerJob).UpdateId(id, update)
+}
 
-	return err
+// MongoGetCrawlerJobs returns all registered reddit crawler jobs
+func MongoGetCrawlerJobs(db *mgo.Database) []CrawlerJobs {
+	crawlerJobs := []CrawlerJobs{}
+	for _, job := range MongoGetAllCrawlerJobs(db, crawlerSourceReddit) {
+		crawlerJobs = append(crawlerJobs, legacyCrawlerJob(job))
+	}
+
+	return crawlerJobs
 }
 
-func MongoUpdateCrawlerJob(mongoClient *mgo.Session, date time.Time) error {
-	query := bson.M{fieldCrawlerJobDate: date}
-	update := bson.M{"$set": bson.M{"occurrence": 0}}
-	err := mongoClient.DB(database).C(collectionCrawlerJobs).Update(query, update)
+func MongoDeleteCrawlerJob(db *mgo.Database, date time.Time) error {
+	return mongoDeleteCrawlerJobByDate(db, crawlerSourceReddit, date)
+}
+
+func MongoUpdateCrawlerJob(db *mgo.Database, date time.Time) error {
+	return mongoStopCrawlerJobByDate(db, crawlerSourceReddit, date)
+}
 
+func MongoInsertAppReviewCrawlerJobs(db *mgo.Database, appReviewCrawlerJob AppReviewCrawlerJobs) error {
+	job, err := appReviewCrawlerJobFromLegacy(appReviewCrawlerJob)
+	if err != nil {
+		return err
+	}
+	_, err = MongoInsertCrawlerJob(db, job)
 	return err
 }
 
-func MongoInsertAppReviewCrawlerJobs(mongoClient *mgo.Session, appReviewCrawlerJob AppReviewCrawlerJobs) error{
-	appReviewCrawlerJob.Date = time.Now()
-	var v interface{}
-	v = appReviewCrawlerJob
-	fmt.Println("Inserting Data: ")
-	fmt.Printf("%+v\n", v)
-	err := mongoClient.DB(database).C(collectionAppReviewCrawlerJobs).Insert(v)
-	if err != nil && !mgo.IsDup(err){
-		fmt.Println(err)
+func MongoGetAppReviewCrawlerJobs(db *mgo.Database) []AppReviewCrawlerJobs {
+	crawlerJobs := []AppReviewCrawlerJobs{}
+	for _, job := range MongoGetAllCrawlerJobs(db, crawlerSourceAppReview) {
+		crawlerJobs = append(crawlerJobs, legacyAppReviewCrawlerJob(job))
+	}
+
+	return crawlerJobs
+}
+
+func MongoDeleteAppReviewCrawlerJob(db *mgo.Database, date time.Time) error {
+	return mongoDeleteCrawlerJobByDate(db, crawlerSourceAppReview, date)
+}
+
+func MongoUpdateAppReviewCrawlerJob(db *mgo.Database, date time.Time) error {
+	return mongoStopCrawlerJobByDate(db, crawlerSourceAppReview, date)
+}
+
+// mongoDeleteCrawlerJobByDate removes the jobs the legacy endpoints identify by source and date
+func mongoDeleteCrawlerJobByDate(db *mgo.Database, source string, date time.Time) error {
+	var jobs []CrawlerJob
+	err := db.
+		C(collectionCrawlerJob).
+		Find(bson.M{fieldCrawlerJobSource: source, fieldCrawlerJobDate: date}).
+		Select(bson.M{"_id": 1}).
+		All(&jobs)
+	if err != nil {
 		return err
-	}	
+	}
+	for _, job := range jobs {
+		if err := MongoDeleteCrawlerJobById(db, job.Id); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func MongoGetAppReviewCrawlerJobs(mongoClient *mgo.Session) [] AppReviewCrawlerJobs {
-	var crawlerJobs []AppReviewCrawlerJobs
+func mongoStopCrawlerJobByDate(db *mgo.Database, source string, date time.Time) error {
+	job, err := MongoFindCrawlerJob(db, source, date)
+	if err != nil {
+		return err
+	}
 
-	err := mongoClient.
-		DB(database).
-		C(collectionAppReviewCrawlerJobs).Find(bson.M{}).Select(bson.M{"app_name": 1, "date": 1, "app_occurrence": 1, "app_number_posts": 1, "dataset_name": 1, "request": 1}).All(&crawlerJobs)
-	
+	return MongoStopCrawlerJob(db, job.Id)
+}
+
+// MongoLeaseDueCrawlerJobs locks up to limit jobs whose next run is due and which are not leased to a worker.
+// Every job is locked with a single findAndModify, so concurrent workers never lease the same job.
+func MongoLeaseDueCrawlerJobs(db *mgo.Database, worker string, lease time.Duration, limit int) ([]CrawlerJob, error) {
+	jobs := []CrawlerJob{}
+	now := time.Now()
+	query := bson.M{
+		fieldCrawlerJobNextRun: bson.M{"$lte": now},
+		"$or": []bson.M{
+			{fieldCrawlerJobLockedUntil: bson.M{"$exists": false}},
+			{fieldCrawlerJobLockedUntil: bson.M{"$lt": now}},
+		},
+	}
+	change := mgo.Change{
+		Update:    bson.M{"$set": bson.M{fieldCrawlerJobLockedBy: worker, fieldCrawlerJobLockedUntil: now.Add(lease)}},
+		ReturnNew: true,
+	}
+	for len(jobs) < limit {
+		var job CrawlerJob
+		_, err := db.C(collectionCrawlerJob).Find(query).Sort(fieldCrawlerJobNextRun).Apply(change, &job)
+		if err == mgo.ErrNotFound {
+			break
+		} else if err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// MongoSetCrawlerJobSchedule replaces the interval or cron expression of a job and recomputes its next run,
+// the outcome of the last run and a current lease are kept
+func MongoSetCrawlerJobSchedule(db *mgo.Database, id bson.ObjectId, schedule CrawlerSchedule) error {
+	set := bson.M{"schedule.interval_minutes": schedule.IntervalMinutes, "schedule.cron": schedule.Cron}
+	update := bson.M{"$set": set}
+	if schedule.NextRun != nil {
+		set[fieldCrawlerJobNextRun] = schedule.NextRun
+	} else {
+		update["$unset"] = bson.M{fieldCrawlerJobNextRun: ""}
+	}
+	return db.C(collectionCrawlerJob).UpdateId(id, update)
+}
+
+// MongoFinishCrawlerJobRun records the outcome of a run, schedules the next run and releases the lease. It returns
+// mgo.ErrNotFound if the job is not leased to the worker.
+func MongoFinishCrawlerJobRun(db *mgo.Database, id bson.ObjectId, outcome CrawlerRunOutcome) error {
+	query := bson.M{"_id": id, fieldCrawlerJobLockedBy: outcome.Worker}
+	var job CrawlerJob
+	err := db.C(collectionCrawlerJob).Find(query).One(&job)
 	if err != nil {
-		fmt.Println("ERR", err)
-		panic(err)
+		return err
 	}
-	fmt.Printf("getAppReviewCrawlerJobs result:  %v\n", crawlerJobs)
 
-	return crawlerJobs
+	now := time.Now()
+	set := bson.M{"schedule.last_run": now, "schedule.last_outcome": outcome.Outcome, "schedule.last_error": outcome.Error}
+	unset := bson.M{fieldCrawlerJobLockedBy: "", fieldCrawlerJobLockedUntil: ""}
+	if next := nextCrawlerRun(*job.Schedule, now); next != nil {
+		set[fieldCrawlerJobNextRun] = next
+	} else {
+		unset[fieldCrawlerJobNextRun] = ""
+	}
+	return db.C(collectionCrawlerJob).Update(query, bson.M{"$set": set, "$unset": unset})
 }
 
-func MongoDeleteAppReviewCrawlerJob(mongoClient *mgo.Session, date time.Time) error {
-	_, err := mongoClient.
-		DB(database).
-		C(collectionAppReviewCrawlerJobs).
-		RemoveAll(bson.M{fieldCrawlerJobDate: date})
-	
-	return err
+// MongoInsertCrawlerJobRun stores a new run of a crawler job
+func MongoInsertCrawlerJobRun(db *mgo.Database, run CrawlerJobRun) error {
+	return db.C(collectionCrawlerJobRuns).Insert(run)
 }
 
+// MongoGetCrawlerJobRuns returns the runs of a crawler job, the latest first
+func MongoGetCrawlerJobRuns(db *mgo.Database, jobId bson.ObjectId) []CrawlerJobRun {
+	runs := []CrawlerJobRun{}
+	err := db.
+		C(collectionCrawlerJobRuns).
+		Find(bson.M{"job_id": jobId}).
+		Sort("-queued_at").
+		All(&runs)
+	panicError(err)
 
-func MongoUpdateAppReviewCrawlerJob(mongoClient *mgo.Session, date time.Time) error {
-	query := bson.M{fieldCrawlerJobDate: date}
-	update := bson.M{"$set": bson.M{"occurrence": 0}}
-	err := mongoClient.DB(database).C(collectionAppReviewCrawlerJobs).Update(query, update)
+	return runs
+}
+
+// MongoGetCrawlerJobRun returns a run of a crawler job or mgo.ErrNotFound
+func MongoGetCrawlerJobRun(db *mgo.Database, jobId bson.ObjectId, id bson.ObjectId) (CrawlerJobRun, error) {
+	var run CrawlerJobRun
+	err := db.
+		C(collectionCrawlerJobRuns).
+		Find(bson.M{"_id": id, "job_id": jobId}).
+		One(&run)
+
+	return run, err
+}
+
+// MongoUpdateCrawlerJobRun replaces a run if it still has the previous status, otherwise it returns mgo.ErrNotFound.
+// The number of posts of a succeeded run becomes the number of posts of its job.
+func MongoUpdateCrawlerJobRun(db *mgo.Database, run CrawlerJobRun, previousStatus string) error {
+	err := db.C(collectionCrawlerJobRuns).Update(bson.M{"_id": run.Id, "status": previousStatus}, run)
+	if err != nil || run.Status != crawlerRunSucceeded || previousStatus == crawlerRunSucceeded {
+		return err
+	}
 
-	return err 
+	return db.C(collectionCrawlerJob).UpdateId(run.JobId, bson.M{"$set": bson.M{"number_posts": run.NumberPosts}})
 }
 
-func MongoGetAllAnnotationsCodes(mongoClient *mgo.Session) []Annotation {
+func MongoGetAllAnnotationsCodes(db *mgo.Database) []Annotation {
 
     var annotations []Annotation
 
-    err := mongoClient.
-        DB(database).
+    err := db.
         C(collectionAnnotation).Find(bson.M{}).Select(bson.M{"name": 1, "codes.name": 1, "codes.tore": 1, "sentence_tokenization_enabled_for_annotation": 1}).All(&annotations)
 
     if err != nil {
@@ -539,45 +1755,316 @@ func MongoGetAllAnnotationsCodes(mongoClient *mgo.Session) []Annotation {
     return annotations
 }
 
-func MongoInsertManyRecommendations(mongoClient *mgo.Session, recommendations []Recommendation) error {
+// MongoGetAnnotationsForConcordance returns the annotations containing codes with the given name, tore or relationship
+func MongoGetAnnotationsForConcordance(db *mgo.Database, name string, tore string, relationship string) []Annotation {
+	query := bson.M{}
+	if name != "" {
+		query["codes.name"] = caseInsensitiveMatch(name)
+	}
+	if tore != "" {
+		query["codes.tore"] = caseInsensitiveMatch(tore)
+	}
+	if relationship != "" {
+		query["tore_relationships.relationship_name"] = caseInsensitiveMatch(relationship)
+	}
+
+	var annotations []Annotation
+	err := db.
+		C(collectionAnnotation).
+		Find(query).
+		Select(bson.M{"name": 1, "dataset": 1, "docs": 1, "tokens": 1, "codes": 1, "tore_relationships": 1}).
+		All(&annotations)
+	panicError(err)
+
+	return annotations
+}
+
+// MongoGetAnnotationStatistics aggregates code, token and relationship statistics of all annotations matching the query
+func MongoGetAnnotationStatistics(db *mgo.Database, query bson.M, limit int) (AnnotationStatistics, error) {
+	collection := db.C(collectionAnnotation)
+	statistics := AnnotationStatistics{}
+
+	// all token indices referenced by at least one code
+	codedTokens := bson.M{"$filter": bson.M{
+		"input": bson.M{"$reduce": bson.M{
+			"input":        bson.M{"$ifNull": []interface{}{"$codes.tokens", []interface{}{}}},
+			"initialValue": []interface{}{},
+			"in":           bson.M{"$setUnion": []interface{}{"$$value", bson.M{"$ifNull": []interface{}{"$$this", []interface{}{}}}}},
+		}},
+		"as":   "token",
+		"cond": bson.M{"$ne": []interface{}{"$$token", nil}},
+	}}
+
+	var totals struct {
+		NumAnnotations int `bson:"num_annotations"`
+		NumCodes       int `bson:"num_codes"`
+		NumTokens      int `bson:"num_tokens"`
+		NumCodedTokens int `bson:"num_coded_tokens"`
+	}
+	err := collection.Pipe([]bson.M{
+		{"$match": query},
+		{"$project": bson.M{
+			"num_codes":        bson.M{"$size": bson.M{"$ifNull": []interface{}{"$codes", []interface{}{}}}},
+			"num_tokens":       bson.M{"$size": bson.M{"$ifNull": []interface{}{"$tokens", []interface{}{}}}},
+			"num_coded_tokens": bson.M{"$size": codedTokens},
+		}},
+		{"$group": bson.M{
+			"_id":              nil,
+			"num_annotations":  bson.M{"$sum": 1},
+			"num_codes":        bson.M{"$sum": "$num_codes"},
+			"num_tokens":       bson.M{"$sum": "$num_tokens"},
+			"num_coded_tokens": bson.M{"$sum": "$num_coded_tokens"},
+		}},
+	}).One(&totals)
+	if err != nil && err != mgo.ErrNotFound {
+		return statistics, err
+	}
+	statistics.NumAnnotations = totals.NumAnnotations
+	statistics.NumCodes = totals.NumCodes
+	statistics.NumTokens = totals.NumTokens
+	statistics.NumCodedTokens = totals.NumCodedTokens
+	if totals.NumTokens > 0 {
+		statistics.CodedTokenShare = float64(totals.NumCodedTokens) / float64(totals.NumTokens)
+	}
 
-	fmt.Println("Inserting Recommendations")
+	statistics.ToreCounts, err = aggregateCounts(collection, query, []bson.M{
+		{"$unwind": "$codes"},
+		{"$match": bson.M{"codes.tore": bson.M{"$nin": []interface{}{"", nil}}}},
+	}, "$codes.tore", 0)
+	if err != nil {
+		return statistics, err
+	}
 
-	docs := make([]interface{}, len(recommendations))
-	for i, u := range recommendations {
-		docs[i] = u
+	statistics.CodeNameCounts, err = aggregateCounts(collection, query, []bson.M{
+		{"$unwind": "$codes"},
+		{"$match": bson.M{"codes.name": bson.M{"$nin": []interface{}{"", nil}}}},
+	}, bson.M{"$toLower": "$codes.name"}, limit)
+	if err != nil {
+		return statistics, err
 	}
 
-	err := mongoClient.DB(database).C(collectionRecommendation).Insert(docs...)
+	statistics.LemmaCounts, err = aggregateCounts(collection, query, []bson.M{
+		{"$unwind": "$codes"},
+		{"$unwind": "$codes.tokens"},
+		{"$project": bson.M{"lemma": bson.M{"$arrayElemAt": []interface{}{"$tokens.lemma", "$codes.tokens"}}}},
+		{"$match": bson.M{"lemma": bson.M{"$nin": []interface{}{"", nil}}}},
+	}, bson.M{"$toLower": "$lemma"}, limit)
 	if err != nil {
-		fmt.Println(err)
-		return err
+		return statistics, err
 	}
-	return nil
+
+	statistics.RelationshipCounts, err = aggregateCounts(collection, query, []bson.M{
+		{"$unwind": "$tore_relationships"},
+		{"$match": bson.M{"tore_relationships.relationship_name": bson.M{"$nin": []interface{}{"", nil}}}},
+	}, "$tore_relationships.relationship_name", 0)
+	if err != nil {
+		return statistics, err
+	}
+
+	statistics.DocumentCoverage = []DocumentCoverage{}
+	err = collection.Pipe([]bson.M{
+		{"$match": query},
+		{"$project": bson.M{"name": 1, "docs": 1, "coded_tokens": codedTokens}},
+		{"$unwind": "$docs"},
+		{"$project": bson.M{
+			"_id":             0,
+			"annotation_name": "$name",
+			"document":        "$docs.name",
+			"num_tokens":      bson.M{"$subtract": []interface{}{"$docs.end_index", "$docs.begin_index"}},
+			"num_coded_tokens": bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": "$coded_tokens",
+				"as":    "token",
+				"cond": bson.M{"$and": []interface{}{
+					bson.M{"$gte": []interface{}{"$$token", "$docs.begin_index"}},
+					bson.M{"$lt": []interface{}{"$$token", "$docs.end_index"}},
+				}},
+			}}},
+		}},
+	}).All(&statistics.DocumentCoverage)
+	if err != nil {
+		return statistics, err
+	}
+	for i, coverage := range statistics.DocumentCoverage {
+		if coverage.NumTokens > 0 {
+			statistics.DocumentCoverage[i].Coverage = float64(coverage.NumCodedTokens) / float64(coverage.NumTokens)
+		}
+	}
+
+	return statistics, nil
 }
 
-// MongoGetRecommendation returns a recommendation
-func MongoDeleteRecommendationAll(mongoClient *mgo.Session) error {
-	
-	fmt.Println("Deleting Recommendations")
-	
-	_, err := mongoClient.
-		DB(database).
-		C(collectionRecommendation).
-		RemoveAll(bson.M{})
+// aggregateCounts groups the unwound annotation entries by key and returns the most frequent ones first
+func aggregateCounts(collection *mgo.Collection, query bson.M, stages []bson.M, key interface{}, limit int) ([]StatisticsCount, error) {
+	pipeline := []bson.M{{"$match": query}}
+	pipeline = append(pipeline, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": key, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{{Name: "count", Value: -1}, {Name: "_id", Value: 1}}},
+	)
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
 
-	if err != nil { 
-		fmt.Println(err)
+	counts := []StatisticsCount{}
+	err := collection.Pipe(pipeline).All(&counts)
+	return counts, err
+}
+
+func caseInsensitiveMatch(value string) bson.RegEx {
+	return bson.RegEx{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"}
+}
+
+// MongoReplaceRecommendations atomically replaces all recommendations. The new recommendations are written to a
+// staging collection that is renamed over the live one, so lookups never see an empty or partial collection and
+// a failed insert leaves the current recommendations untouched. Every call stages to its own collection, concurrent
+// replacements do not interfere and the last rename wins.
+func MongoReplaceRecommendations(db *mgo.Database, recommendations []Recommendation) (RecommendationUpdateResponse, error) {
+	response := RecommendationUpdateResponse{}
+
+	var current []Recommendation
+	err := db.C(collectionRecommendation).Find(bson.M{}).All(&current)
+	if err != nil {
+		return response, err
 	}
 
-	return err
+	staging := db.C(collectionRecommendationStage + "_" + bson.NewObjectId().Hex())
+	err = staging.EnsureIndex(mgo.Index{
+		Key:        []string{fieldRecommendationCodename},
+		Unique:     true,
+		Background: true,
+		Sparse:     true,
+	})
+	if err != nil {
+		_ = staging.DropCollection()
+		return response, err
+	}
+	if len(recommendations) > 0 {
+		docs := make([]interface{}, len(recommendations))
+		for i, recommendation := range recommendations {
+			docs[i] = recommendation
+		}
+		if err := staging.Insert(docs...); err != nil {
+			_ = staging.DropCollection()
+			return response, err
+		}
+	}
+
+	err = mongoReplaceCollection(db, staging, collectionRecommendation)
+	if err != nil {
+		_ = staging.DropCollection()
+		return response, err
+	}
+
+	return compareRecommendations(current, recommendations), nil
+}
+
+// mongoReplaceCollection renames a staging collection to the collection it replaces in a single operation
+func mongoReplaceCollection(db *mgo.Database, staging *mgo.Collection, collection string) error {
+	return db.Session.DB("admin").Run(bson.D{
+		{Name: "renameCollection", Value: db.Name + "." + staging.Name},
+		{Name: "to", Value: db.Name + "." + collection},
+		{Name: "dropTarget", Value: true},
+	}, nil)
+}
+
+func isNamespaceNotFound(err error) bool {
+	if queryErr, ok := err.(*mgo.QueryError); ok && queryErr.Code == 26 {
+		return true
+	}
+	return err.Error() == "ns not found"
+}
+
+func compareRecommendations(current []Recommendation, replacement []Recommendation) RecommendationUpdateResponse {
+	response := RecommendationUpdateResponse{}
+	currentTores := make(map[string][]string)
+	for _, recommendation := range current {
+		currentTores[recommendation.Codename] = recommendation.Torecodes
+	}
+	for _, recommendation := range replacement {
+		tores, ok := currentTores[recommendation.Codename]
+		switch {
+		case !ok:
+			response.Inserted++
+		case equalStrings(tores, recommendation.Torecodes):
+			response.Unchanged++
+		default:
+			response.Updated++
+		}
+		delete(currentTores, recommendation.Codename)
+	}
+	response.Removed = len(currentTores)
+	return response
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MongoUpsertRecommendation creates or replaces the recommendation of a codename and returns if it was inserted
+func MongoUpsertRecommendation(db *mgo.Database, recommendation Recommendation) (bool, error) {
+	query := bson.M{fieldRecommendationCodename: recommendation.Codename}
+	info, err := db.C(collectionRecommendation).Upsert(query, bson.M{"$set": recommendation})
+	if err != nil {
+		return false, err
+	}
+	return info.UpsertedId != nil, nil
+}
+
+// MongoPatchRecommendation adds and removes torecodes of an existing recommendation, returns mgo.ErrNotFound if it does not exist
+func MongoPatchRecommendation(db *mgo.Database, codename string, update RecommendationUpdate) (Recommendation, error) {
+	var recommendation Recommendation
+	collection := db.C(collectionRecommendation)
+	query := bson.M{fieldRecommendationCodename: codename}
+	if err := collection.Find(query).One(&recommendation); err != nil {
+		return recommendation, err
+	}
+
+	if len(update.AddTorecodes) > 0 {
+		if err := collection.Update(query, bson.M{"$addToSet": bson.M{"torecodes": bson.M{"$each": update.AddTorecodes}}}); err != nil {
+			return recommendation, err
+		}
+	}
+	if len(update.RemoveTorecodes) > 0 {
+		if err := collection.Update(query, bson.M{"$pull": bson.M{"torecodes": bson.M{"$in": update.RemoveTorecodes}}}); err != nil {
+			return recommendation, err
+		}
+	}
+
+	err := collection.Find(query).One(&recommendation)
+	return recommendation, err
+}
+
+// MongoDeleteRecommendation returns mgo.ErrNotFound if there is no recommendation for the codename
+func MongoDeleteRecommendation(db *mgo.Database, codename string) error {
+	return db.
+		C(collectionRecommendation).
+		Remove(bson.M{fieldRecommendationCodename: codename})
+}
+
+// MongoGetRecommendationsForCodenames returns the stored recommendations of the codenames
+func MongoGetRecommendationsForCodenames(db *mgo.Database, codenames []string) []Recommendation {
+	var recommendations []Recommendation
+	err := db.
+		C(collectionRecommendation).
+		Find(bson.M{fieldRecommendationCodename: bson.M{"$in": codenames}}).
+		All(&recommendations)
+	panicError(err)
+
+	return recommendations
 }
 
 // MongoGetRecommendation returns a recommendation
-func MongoGetRecommendation(mongoClient *mgo.Session, codename string) Recommendation {
+func MongoGetRecommendation(db *mgo.Database, codename string) Recommendation {
 	var recommendations []Recommendation
-	err := mongoClient.
-		DB(database).
+	err := db.
 		C(collectionRecommendation).
 		Find(bson.M{fieldRecommendationCodename: codename}).
 		All(&recommendations)
@@ -594,4 +2081,60 @@ func MongoGetRecommendation(mongoClient *mgo.Session, codename string) Recommend
 	}
 	
 	return recommendations[0]
-}
\ No newline at end of file
+}
+// MongoMigrateProjects creates the index of the project registry and registers the default project. The members are
+// added to the default project, which holds the data stored before projects and is only open to its members.
+func MongoMigrateProjects(db *mgo.Database, members []string) {
+	err := db.C(collectionProject).EnsureIndex(mgo.Index{Key: []string{fieldProjectName}, Unique: true, Background: true})
+	panicError(err)
+	_, err = db.C(collectionProject).Upsert(
+		bson.M{fieldProjectName: defaultProject},
+		bson.M{"$setOnInsert": bson.M{fieldProjectName: defaultProject, "description": "Default project", "created_at": time.Now()}},
+	)
+	panicError(err)
+	err = db.C(collectionProject).Update(
+		bson.M{fieldProjectName: defaultProject},
+		bson.M{"$addToSet": bson.M{fieldProjectMembers: bson.M{"$each": append([]string{}, members...)}}},
+	)
+	panicError(err)
+}
+
+// MongoInitializeProject creates the indexes and the default annotation scheme in the database of a new project
+func MongoInitializeProject(db *mgo.Database) {
+	MongoCreateCollectionIndexes(db)
+	MongoMigrateAnnotationSchemes(db)
+}
+
+// MongoGetProjects returns all projects, or the projects of a member if the member is not empty
+func MongoGetProjects(db *mgo.Database, member string) []Project {
+	query := bson.M{}
+	if member != "" {
+		query[fieldProjectMembers] = member
+	}
+	projects := []Project{}
+	err := db.C(collectionProject).Find(query).Sort(fieldProjectName).All(&projects)
+	panicError(err)
+
+	return projects
+}
+
+// MongoGetProject returns a project, mgo.ErrNotFound if it does not exist
+func MongoGetProject(db *mgo.Database, name string) (Project, error) {
+	var project Project
+	err := db.C(collectionProject).Find(bson.M{fieldProjectName: name}).One(&project)
+	return project, err
+}
+
+// MongoInsertProject registers a new project
+func MongoInsertProject(db *mgo.Database, project Project) error {
+	return db.C(collectionProject).Insert(project)
+}
+
+// MongoSetProjectMember adds a member to a project or removes it
+func MongoSetProjectMember(db *mgo.Database, name string, member string, isMember bool) error {
+	update := bson.M{"$pull": bson.M{fieldProjectMembers: member}}
+	if isMember {
+		update = bson.M{"$addToSet": bson.M{fieldProjectMembers: member}}
+	}
+	return db.C(collectionProject).Update(bson.M{fieldProjectName: name}, update)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	defaultProject = "default"
+	projectKey     = "X-Project"
+)
+
+// projectNamePattern keeps project database names within the limits of MongoDB
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+type projectContextKey struct{}
+
+// projectDatabase returns the name of the database of a project, the default project uses the configured database
+func projectDatabase(project string) string {
+	if project == defaultProject {
+		return database
+	}
+	return database + "_" + project
+}
+
+// validateProject checks the name and members of a new project and returns it with trimmed members
+func validateProject(project Project) (Project, []ValidationError) {
+	var errs []ValidationError
+	if !projectNamePattern.MatchString(project.Name) {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must be 1 to 32 lowercase letters, digits, '_' or '-' starting with a letter or digit"})
+	}
+	members := []string{}
+	for i, member := range project.Members {
+		member = strings.TrimSpace(member)
+		switch {
+		case member == "":
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("members[%d]", i), Message: "member must not be empty"})
+		case !containsString(members, member):
+			members = append(members, member)
+		}
+	}
+	project.Members = members
+	return project, errs
+}
+
+// resolveProject returns the project a request is scoped to, given by the X-Project header or the default project.
+// Admins and the crawler service access every project, other callers only the projects they are members of.
+func resolveProject(r *http.Request, principal Principal) (string, int, error) {
+	project := r.Header.Get(projectKey)
+	if project == "" {
+		project = defaultProject
+	}
+	privileged := principal.Role == roleAdmin || principal.Role == roleCrawlerService
+	if privileged && project == defaultProject {
+		return project, http.StatusOK, nil
+	}
+
+	m := mongoClient.Copy()
+	defer m.Close()
+	stored, err := MongoGetProject(m.DB(database), project)
+	if err == mgo.ErrNotFound {
+		return project, http.StatusNotFound, fmt.Errorf("project %s does not exist", project)
+	} else if err != nil {
+		return project, http.StatusInternalServerError, err
+	}
+	if !privileged && !containsString(stored.Members, principal.Name) {
+		return project, http.StatusForbidden, fmt.Errorf("%s is not a member of project %s", principal.Name, project)
+	}
+	return project, http.StatusOK, nil
+}
+
+// defaultProjectMembers returns the members the default project is seeded with, configured as comma separated list
+// with DEFAULT_PROJECT_MEMBERS
+func defaultProjectMembers() []string {
+	var members []string
+	for _, member := range strings.Split(os.Getenv("DEFAULT_PROJECT_MEMBERS"), ",") {
+		if member = strings.TrimSpace(member); member != "" {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// requestProject returns the project of a request resolved by allow
+func requestProject(r *http.Request) string {
+	if project, ok := r.Context().Value(projectContextKey{}).(string); ok {
+		return project
+	}
+	return defaultProject
+}
+
+// requestDatabase returns a new session on the database of the project of the request, the caller closes its session
+func requestDatabase(r *http.Request) *mgo.Database {
+	return mongoClient.Copy().DB(projectDatabase(requestProject(r)))
+}
+
+func withProject(r *http.Request, project string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), projectContextKey{}, project))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	recommendationMatchName  = "name"
+	recommendationMatchLemma = "lemma"
+	recommendationMatchAny   = "any"
+)
+
+// recommendationKey identifies one counter of the recommendation statistics
+type recommendationKey struct {
+	Scheme string
+	Kind   string
+	Term   string
+	Tore   string
+}
+
+// normalizeTerm makes code names and lemmas comparable case-insensitively and independent of whitespace
+func normalizeTerm(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
+
+// codeLemmaTerm returns the normalized lemmas of the code tokens in token order
+func codeLemmaTerm(code Code, tokens []Token) string {
+	var lemmas []string
+	for _, index := range validTokenIndices(code.Tokens, len(tokens)) {
+		lemma := tokens[index].Lemma
+		if lemma == "" {
+			lemma = tokens[index].Name
+		}
+		lemmas = append(lemmas, lemma)
+	}
+	return normalizeTerm(strings.Join(lemmas, " "))
+}
+
+// recommendationCounts counts how often each code name and lemma was assigned to each tore in the annotation
+func recommendationCounts(annotation Annotation) map[recommendationKey]int {
+	counts := make(map[recommendationKey]int)
+	scheme := schemeOrDefault(annotation.Scheme)
+	for _, code := range annotation.Codes {
+		// suggestions of detection methods are only learned from once an annotator accepted them
+		if code.Tore == "" || code.Suggested {
+			continue
+		}
+		if name := normalizeTerm(code.Name); name != "" {
+			counts[recommendationKey{Scheme: scheme, Kind: recommendationMatchName, Term: name, Tore: code.Tore}]++
+		}
+		if lemma := codeLemmaTerm(code, annotation.Tokens); lemma != "" {
+			counts[recommendationKey{Scheme: scheme, Kind: recommendationMatchLemma, Term: lemma, Tore: code.Tore}]++
+		}
+	}
+	return counts
+}
+
+// diffRecommendationCounts returns the changes needed to turn the old counts into the new ones
+func diffRecommendationCounts(old map[recommendationKey]int, updated map[recommendationKey]int) map[recommendationKey]int {
+	deltas := make(map[recommendationKey]int)
+	for key, count := range updated {
+		if delta := count - old[key]; delta != 0 {
+			deltas[key] = delta
+		}
+	}
+	for key, count := range old {
+		if _, ok := updated[key]; !ok {
+			deltas[key] = -count
+		}
+	}
+	return deltas
+}
+
+// rankToreSuggestions sums the statistics per tore and orders the tores by how often they were assigned
+func rankToreSuggestions(statistics []RecommendationStatistic) []ToreSuggestion {
+	countsByTore := make(map[string]int)
+	total := 0
+	for _, statistic := range statistics {
+		if statistic.Count <= 0 {
+			continue
+		}
+		countsByTore[statistic.Tore] += statistic.Count
+		total += statistic.Count
+	}
+
+	suggestions := []ToreSuggestion{}
+	for tore, count := range countsByTore {
+		suggestions = append(suggestions, ToreSuggestion{Tore: tore, Count: count, Confidence: float64(count) / float64(total)})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Tore < suggestions[j].Tore
+	})
+	return suggestions
+}
+
+// recommendationKinds returns the statistic kinds used for a match mode, or nil for an unknown mode
+func recommendationKinds(match string) []string {
+	switch match {
+	case recommendationMatchName:
+		return []string{recommendationMatchName}
+	case recommendationMatchLemma:
+		return []string{recommendationMatchLemma}
+	case recommendationMatchAny, "":
+		return []string{recommendationMatchName, recommendationMatchLemma}
+	}
+	return nil
+}
+
+// groupRecommendationStatistics groups the statistics by kind and term, the tore of the keys is left empty
+func groupRecommendationStatistics(statistics []RecommendationStatistic) map[recommendationKey][]RecommendationStatistic {
+	groups := make(map[recommendationKey][]RecommendationStatistic)
+	for _, statistic := range statistics {
+		key := recommendationKey{Kind: statistic.Kind, Term: statistic.Term}
+		groups[key] = append(groups[key], statistic)
+	}
+	return groups
+}
+
+// rankGroupedSuggestions ranks the tores of the first kind of the match mode that knows its term, termsByKind
+// gives the term to look up per kind
+func rankGroupedSuggestions(groups map[recommendationKey][]RecommendationStatistic, match string, termsByKind map[string]string) (string, []ToreSuggestion) {
+	for _, kind := range recommendationKinds(match) {
+		suggestions := rankToreSuggestions(groups[recommendationKey{Kind: kind, Term: termsByKind[kind]}])
+		if len(suggestions) > 0 {
+			return kind, suggestions
+		}
+	}
+	return "", []ToreSuggestion{}
+}
+
+// recommendTerms returns the suggestions for each term, looking the term up as code name and as lemma
+func recommendTerms(statistics []RecommendationStatistic, terms []string, match string) []TermRecommendation {
+	groups := groupRecommendationStatistics(statistics)
+	recommendations := make([]TermRecommendation, 0, len(terms))
+	for _, term := range terms {
+		term = normalizeTerm(term)
+		matchedBy, suggestions := rankGroupedSuggestions(groups, match, map[string]string{
+			recommendationMatchName:  term,
+			recommendationMatchLemma: term,
+		})
+		recommendations = append(recommendations, TermRecommendation{Term: term, MatchedBy: matchedBy, Suggestions: suggestions})
+	}
+	return recommendations
+}
+
+// uncodedTokens returns the indices of the tokens that are not part of any code
+func uncodedTokens(annotation Annotation) []int {
+	coded := make(map[int]bool)
+	for _, code := range annotation.Codes {
+		for _, index := range validTokenIndices(code.Tokens, len(annotation.Tokens)) {
+			coded[index] = true
+		}
+	}
+
+	var indices []int
+	for i, token := range annotation.Tokens {
+		if !coded[i] && normalizeTerm(token.Name) != "" {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// tokenLookupTerms returns the terms of an annotation token per statistic kind
+func tokenLookupTerms(token Token) map[string]string {
+	lemma := token.Lemma
+	if lemma == "" {
+		lemma = token.Name
+	}
+	return map[string]string{
+		recommendationMatchName:  normalizeTerm(token.Name),
+		recommendationMatchLemma: normalizeTerm(lemma),
+	}
+}
+
+// recommendTokens returns the suggestions for the tokens at the given indices of the annotation
+func recommendTokens(statistics []RecommendationStatistic, annotation Annotation, indices []int, match string) []TokenRecommendation {
+	groups := groupRecommendationStatistics(statistics)
+	recommendations := make([]TokenRecommendation, 0, len(indices))
+	for _, index := range indices {
+		token := annotation.Tokens[index]
+		matchedBy, suggestions := rankGroupedSuggestions(groups, match, tokenLookupTerms(token))
+		recommendations = append(recommendations, TokenRecommendation{
+			Index:       index,
+			Name:        token.Name,
+			Lemma:       token.Lemma,
+			MatchedBy:   matchedBy,
+			Suggestions: suggestions,
+		})
+	}
+	return recommendations
+}
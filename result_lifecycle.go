@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	resultScheduled = "scheduled"
+	resultRunning   = "running"
+	resultFinished  = "finished"
+	resultFailed    = "failed"
+	resultCancelled = "cancelled"
+
+	defaultResultTimeout    = 24 * time.Hour
+	resultTimeoutCheckEvery = time.Minute
+)
+
+// resultTransitions lists the statuses a result can move to from each status, finished and cancelled results can not
+// change. A failed result still becomes finished, e.g. when the method finishes after the running timeout.
+var resultTransitions = map[string][]string{
+	resultScheduled: {resultRunning, resultCancelled},
+	resultRunning:   {resultFinished, resultFailed, resultCancelled},
+	resultFailed:    {resultFinished},
+}
+
+var resultStatuses = []string{resultScheduled, resultRunning, resultFinished, resultFailed, resultCancelled}
+
+// legacyResultStatuses maps the statuses results were stored with before the lifecycle
+var legacyResultStatuses = map[string]string{
+	"started":   resultRunning,
+	"done":      resultFinished,
+	"completed": resultFinished,
+	"error":     resultFailed,
+	"canceled":  resultCancelled,
+}
+
+// migratedResultStatus returns the lifecycle status of a legacy status, empty and unknown statuses become failed so
+// that the method can still post the finished result
+func migratedResultStatus(status string) string {
+	normalized := strings.ToLower(strings.TrimSpace(status))
+	if containsString(resultStatuses, normalized) {
+		return normalized
+	}
+	if migrated, ok := legacyResultStatuses[normalized]; ok {
+		return migrated
+	}
+	return resultFailed
+}
+
+func isFinalResultStatus(status string) bool {
+	return status == resultFinished || status == resultFailed || status == resultCancelled
+}
+
+// validateResultTransition checks that a result can move from one status to the other
+func validateResultTransition(from string, to string) []ValidationError {
+	if !containsString(resultStatuses, to) {
+		return []ValidationError{{Field: "status", Message: fmt.Sprintf("status must be one of %v", resultStatuses)}}
+	}
+	if from != to && !containsString(resultTransitions[from], to) {
+		return []ValidationError{{Field: "status", Message: fmt.Sprintf("a %s result can not become %s", from, to)}}
+	}
+	return nil
+}
+
+// applyResultStatus sets the status history of a posted result. A new result starts with its posted status, a
+// stored result keeps its history and records a transition if the posted status differs from the stored one.
+func applyResultStatus(result Result, stored Result, exists bool, now time.Time) (Result, []ValidationError) {
+	if !exists {
+		if errs := validateResultTransition(result.Status, result.Status); len(errs) > 0 {
+			return result, errs
+		}
+		result.StatusChangedAt = &now
+		result.StatusHistory = []ResultTransition{{Status: result.Status, At: now}}
+		return result, nil
+	}
+
+	if errs := validateResultTransition(stored.Status, result.Status); len(errs) > 0 {
+		return result, errs
+	}
+	result.StatusChangedAt = stored.StatusChangedAt
+	result.StatusHistory = stored.StatusHistory
+	if result.Status != stored.Status {
+		result.StatusChangedAt = &now
+		result.StatusHistory = append(result.StatusHistory, ResultTransition{Status: result.Status, At: now})
+	}
+	return result, nil
+}
+
+// resultTimeout returns how long a result may be running before it is marked failed, it is configured with
+// RESULT_RUNNING_TIMEOUT as duration like "6h", "0" disables the timeout
+func resultTimeout() time.Duration {
+	value := os.Getenv("RESULT_RUNNING_TIMEOUT")
+	if value == "" {
+		return defaultResultTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		fmt.Printf("ERROR invalid RESULT_RUNNING_TIMEOUT %q, using %s\n", value, defaultResultTimeout)
+		return defaultResultTimeout
+	}
+	return timeout
+}
+
+// failStuckResults periodically marks results of all projects failed that are running for longer than the timeout
+func failStuckResults(timeout time.Duration) {
+	if timeout == 0 {
+		return
+	}
+	for now := range time.Tick(resultTimeoutCheckEvery) {
+		failStuckProjectResults(timeout, now)
+	}
+}
+
+// failStuckProjectResults marks the stuck results of every project failed. Errors are logged and the check is repeated
+// on the next tick, it must not crash the service.
+func failStuckProjectResults(timeout time.Duration, now time.Time) {
+	m := mongoClient.Copy()
+	defer m.Close()
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Printf("ERROR failing stuck results: %v\n", err)
+		}
+	}()
+	for _, project := range MongoGetProjects(m.DB(database), "") {
+		failed, err := MongoFailStuckResults(m.DB(projectDatabase(project.Name)), timeout, now)
+		if err != nil {
+			fmt.Printf("ERROR failing stuck results of project %s: %s\n", project.Name, err)
+		} else if failed > 0 {
+			fmt.Printf("Marked %d results of project %s failed that were running for more than %s\n", failed, project.Name, timeout)
+		}
+	}
+}
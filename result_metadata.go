@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	maxResultNameLength  = 200
+	maxResultNotesLength = 10000
+	maxResultTags        = 20
+	maxResultTagLength   = 50
+)
+
+var resultTagPattern = regexp.MustCompile(`^[\pL\pN][\pL\pN _.:-]*$`)
+
+// validateResultPatch checks the fields given in the patch and returns the patch with trimmed name and tags, tags
+// given more than once are only kept once. The name can only be changed once the result is final.
+func validateResultPatch(patch ResultPatch, status string) (ResultPatch, []ValidationError) {
+	var errs []ValidationError
+	if patch.Name != nil {
+		name := strings.TrimSpace(*patch.Name)
+		patch.Name = &name
+		switch {
+		case name == "":
+			errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+		case utf8.RuneCountInString(name) > maxResultNameLength:
+			errs = append(errs, ValidationError{Field: "name", Message: fmt.Sprintf("name must not be longer than %d characters", maxResultNameLength)})
+		case !isFinalResultStatus(status):
+			errs = append(errs, ValidationError{Field: "name", Message: fmt.Sprintf("name of a %s result can not be changed", status)})
+		}
+	}
+
+	if patch.Tags != nil {
+		tags := []string{}
+		for i, tag := range *patch.Tags {
+			tag = strings.TrimSpace(tag)
+			field := fmt.Sprintf("tags[%d]", i)
+			switch {
+			case utf8.RuneCountInString(tag) > maxResultTagLength:
+				errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("tag must not be longer than %d characters", maxResultTagLength)})
+			case !resultTagPattern.MatchString(tag):
+				errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("%q is not a tag, tags start with a letter or digit and may contain spaces, '.', ':', '_' and '-'", tag)})
+			case !containsString(tags, tag):
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) > maxResultTags {
+			errs = append(errs, ValidationError{Field: "tags", Message: fmt.Sprintf("a result can have at most %d tags", maxResultTags)})
+		}
+		patch.Tags = &tags
+	}
+
+	if patch.Notes != nil && utf8.RuneCountInString(*patch.Notes) > maxResultNotesLength {
+		errs = append(errs, ValidationError{Field: "notes", Message: fmt.Sprintf("notes must not be longer than %d characters", maxResultNotesLength)})
+	}
+	return patch, errs
+}
+
+// set returns the fields to update for the patch
+func (p ResultPatch) set() bson.M {
+	set := bson.M{}
+	if p.Name != nil {
+		set["name"] = *p.Name
+	}
+	if p.Tags != nil {
+		set[fieldResultTags] = *p.Tags
+	}
+	if p.Notes != nil {
+		set["notes"] = *p.Notes
+	}
+	if p.Pinned != nil {
+		set[fieldResultPinned] = *p.Pinned
+	}
+	return set
+}
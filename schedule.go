@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	crawlerRunSucceeded = "succeeded"
+	crawlerRunFailed    = "failed"
+
+	defaultCrawlerLease = 15 * time.Minute
+	maxCrawlerLease     = 24 * time.Hour
+)
+
+// cronSchedule is a parsed five field cron expression: minute, hour, day of month, month and day of week.
+// Each field is a bit set of the allowed values.
+type cronSchedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// like cron, a job runs if either the day of month or the day of week matches when both are restricted
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	// 7 is an alias for sunday
+	{"day of week", 0, 7},
+}
+
+// parseCron parses a standard cron expression like "30 2 * * 1-5" or "*/15 * * * *"
+func parseCron(expression string) (cronSchedule, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(cronFields) {
+		return cronSchedule{}, fmt.Errorf("cron expression needs %d fields, got %d", len(cronFields), len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return cronSchedule{}, err
+		}
+		sets[i] = set
+	}
+	// sundays given as 7 also run on day 0 of time.Weekday
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return cronSchedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(value, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			parsed, err := strconv.Atoi(item[i+1:])
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", item[i+1:], field.name)
+			}
+			step = parsed
+			item = item[:i]
+		}
+
+		begin, end := field.min, field.max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if begin, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", bounds[0], field.name)
+			}
+			end = begin
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", bounds[1], field.name)
+				}
+			} else if step > 1 {
+				end = field.max
+			}
+		}
+		if begin < field.min || end > field.max || begin > end {
+			return 0, fmt.Errorf("%s field must be within %d-%d", field.name, field.min, field.max)
+		}
+		for v := begin; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (c cronSchedule) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// next returns the first time after the given time matching the schedule, or the zero time if there is none
+// within the next five years (e.g. for "0 0 31 2 *")
+func (c cronSchedule) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// validateCrawlerSchedule checks that a schedule has either a positive interval or a valid cron expression
+func validateCrawlerSchedule(schedule CrawlerSchedule) []ValidationError {
+	var errs []ValidationError
+	if schedule.IntervalMinutes < 0 {
+		errs = append(errs, ValidationError{Field: "schedule.interval_minutes", Message: "interval must not be negative"})
+	}
+	if schedule.IntervalMinutes > 0 && schedule.Cron != "" {
+		errs = append(errs, ValidationError{Field: "schedule", Message: "either an interval or a cron expression can be given, not both"})
+	}
+	if schedule.Cron != "" {
+		if _, err := parseCron(schedule.Cron); err != nil {
+			errs = append(errs, ValidationError{Field: "schedule.cron", Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// nextCrawlerRun returns the next run of the schedule after the given time, nil if the job does not repeat
+func nextCrawlerRun(schedule CrawlerSchedule, after time.Time) *time.Time {
+	var next time.Time
+	if schedule.IntervalMinutes > 0 {
+		next = after.Add(time.Duration(schedule.IntervalMinutes) * time.Minute)
+	} else if schedule.Cron != "" {
+		cron, err := parseCron(schedule.Cron)
+		if err != nil {
+			return nil
+		}
+		next = cron.next(after)
+	}
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// legacyCrawlerSchedule turns the occurrence of jobs without a schedule into a schedule repeating every
+// occurrence days, as the crawler UI uses the occurrence as number of days between runs
+func legacyCrawlerSchedule(occurrence int) *CrawlerSchedule {
+	if occurrence <= 0 {
+		return nil
+	}
+	return &CrawlerSchedule{IntervalMinutes: occurrence * 24 * 60}
+}
@@ -8,8 +8,10 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"io/ioutil"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -18,17 +20,43 @@ import (
 const (
 	contentTypeKey     = "Content-Type"
 	contentTypeValJSON = "application/json"
+
+	defaultStatisticsLimit      = 20
+	maxRecommendationBatchTerms = 1000
 )
 
 var mongoClient *mgo.Session
 
 func main() {
+	if name := os.Getenv("MONGO_DATABASE"); name != "" {
+		database = name
+	}
 	mongoClient = MongoGetSession(os.Getenv("MONGO_IP"), os.Getenv("MONGO_USERNAME"), os.Getenv("MONGO_PASSWORD"), database)
-	MongoCreateCollectionIndexes(mongoClient)
+	db := mongoClient.DB(database)
+	MongoCreateCollectionIndexes(db)
+	MongoMigrateAnnotationSchemes(db)
+	MongoMigrateToreTypes(db)
+	MongoMigrateRelationshipNames(db)
+	MongoMigrateRecommendationStatistics(db)
+	MongoMigrateCrawlerJobs(db)
+	MongoMigrateCrawlerSchedules(db)
+	MongoMigrateGroundTruth(db)
+	MongoMigrateResultStatuses(db)
+	MongoMigrateProjects(db, defaultProjectMembers())
+	go failStuckResults(resultTimeout())
+
+	var err error
+	authn, err = loadAuthenticator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if authn.disabled {
+		fmt.Println("WARNING authentication is disabled")
+	}
 
-	allowedHeaders := handlers.AllowedHeaders([]string{"X-Requested-With"})
-	allowedOrigins := handlers.AllowedOrigins([]string{"*"})
-	allowedMethods := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"})
+	allowedHeaders := handlers.AllowedHeaders([]string{"X-Requested-With", contentTypeKey, authorizationKey, apiKeyKey, projectKey})
+	allowedOrigins := handlers.AllowedOriginValidator(isAllowedOrigin(allowedOrigins()))
+	allowedMethods := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
 
 	router := makeRouter()
 
@@ -39,51 +67,213 @@ func main() {
 func makeRouter() *mux.Router {
 	router := mux.NewRouter()
 
+	// Projects, not scoped to the project of the request
+	router.HandleFunc("/hitec/repository/concepts/store/project/", allowUnscoped(admins, postProject)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/project/all", allowUnscoped(roles, getProjects)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/store/project/{project}/member/{member}", allowUnscoped(admins, putProjectMember)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/project/{project}/member/{member}", allowUnscoped(admins, deleteProjectMember)).Methods("DELETE")
+
 	// Insert
-	router.HandleFunc("/hitec/repository/concepts/store/dataset/", postDataset).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/groundtruth/", postAddGroundTruth).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/detection/result/", postDetectionResult).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/detection/result/name", postUpdateResultName).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/annotation/", postAnnotation).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/agreement/", postAgreement).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/annotation/relationships/", postAllRelationshipNames).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/annotation/tores/", postAllToreTypes).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/reddit_crawler/jobs", postCrawlerJobs).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/app_review_crawler/jobs", postAppReviewCrawlerJobs).Methods("POST")
-	router.HandleFunc("/hitec/repository/concepts/store/recommendations/", postRecommendations).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/dataset/", allow(datasetWriters, postDataset)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/dataset/{dataset}/ingest", allow(datasetWriters, postDatasetIngest)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/groundtruth/", allow(researchers, postAddGroundTruth)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/dataset/{dataset}/groundtruth/", allow(researchers, postGroundTruth)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/groundtruth/{name}/element/{id}", allow(researchers, putTruthElement)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/detection/result/", allow(researchers, postDetectionResult)).Methods("POST")
+	// deprecated, superseded by the PATCH of a result
+	router.HandleFunc("/hitec/repository/concepts/store/detection/result/name", allow(researchers, postUpdateResultName)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/detection/result/{result}/evaluation", allow(researchers, postResultEvaluation)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/detection/result/{result}/status", allow(researchers, postResultStatus)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/detection/result/{result}/annotation", allow(researchers, postResultAnnotation)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/detection/result/{result}", allow(researchers, patchResult)).Methods("PATCH")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/", allow(readers, postAnnotation)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/initialize/", allow(researchers, postInitializeAnnotation)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/name/{annotation}/clone", allow(researchers, postCloneAnnotation)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/name/{annotation}/assignment", allow(readers, putAnnotationAssignment)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/name/{annotation}/done/{document}", allow(readers, putAnnotationDocumentDone)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/name/{annotation}/done/{document}", allow(readers, deleteAnnotationDocumentDone)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/store/agreement/", allow(researchers, postAgreement)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/relationships/", allow(researchers, postAllRelationshipNames)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/tores/", allow(researchers, postAllToreTypes)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/reddit_crawler/jobs", allow(crawlers, postCrawlerJobs)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/app_review_crawler/jobs", allow(crawlers, postAppReviewCrawlerJobs)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/recommendations/", allow(researchers, postRecommendations)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/tores/category/", allow(researchers, postToreCategory)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/relationships/type/", allow(researchers, postRelationshipType)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/scheme/", allow(researchers, postAnnotationScheme)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/annotation/recommendation/batch", allow(readers, postRecommendationBatch)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/crawler_jobs/due", allow(crawlers, postDueCrawlerJobs)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/crawler/jobs", allow(crawlers, postCrawlerJob)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/crawler/jobs/{id}/runs", allow(crawlers, postCrawlerJobRun)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/crawler/jobs/{id}/outcome", allow(crawlers, postCrawlerJobOutcome)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/{crawler:reddit_crawler|app_review_crawler}/jobs/{job}/runs", allow(crawlers, postCrawlerJobRun)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/{crawler:reddit_crawler|app_review_crawler}/jobs/{job}/outcome", allow(crawlers, postCrawlerJobOutcome)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/store/recommendations/rebuild", allow(admins, postRebuildRecommendations)).Methods("POST")
 
 	// Get
-	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}", getDataset).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/dataset/all", getAllDatasets).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/detection/result/all", getAllDetectionResults).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotation/name/{annotation}", getAnnotation).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/agreement/name/{agreement}", getAgreement).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotation/relationships", getAllRelationshipNames).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotation/tores", getAllToreTypes).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotation/all", getAllAnnotations).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/agreement/all", getAllAgreements).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotation/dataset/{dataset}", getAnnotationsForDataset).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/crawler_jobs/all", getCrawlerJobs).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/app_review_crawler_jobs/all", getAppReviewCrawlerJobs).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotation/recommendationTores/{codename}", getRecommendationTores).Methods("GET")
-	router.HandleFunc("/hitec/repository/concepts/annotationcodes/all", getAllCodesFromAnnotations).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}", allow(readers, getDataset)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/dataset/all", allow(readers, getAllDatasets)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/ingests", allow(readers, getDatasetIngests)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/groundtruth", allow(readers, getGroundTruths)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/groundtruth/{name}", allow(readers, getGroundTruth)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/groundtruth/{name}/coverage", allow(readers, getGroundTruthCoverage)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/detection/result/all", allow(readers, getAllDetectionResults)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/detection/result/comparison", allow(readers, postResultComparison)).Methods("POST")
+	router.HandleFunc("/hitec/repository/concepts/annotation/name/{annotation}", allow(readers, getAnnotation)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/agreement/name/{agreement}", allow(readers, getAgreement)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/relationships", allow(readers, getAllRelationshipNames)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/tores", allow(readers, getAllToreTypes)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/tores/categories", allow(readers, getToreCategories)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/relationships/types", allow(readers, getRelationshipTypes)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/schemes", allow(readers, getAnnotationSchemes)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/all", allow(readers, getAllAnnotations)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/progress", allow(readers, getAnnotationProgress)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/assignee/{assignee}/queue", allow(readers, getAnnotatorQueue)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/agreement/all", allow(readers, getAllAgreements)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/dataset/{dataset}", allow(readers, getAnnotationsForDataset)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/crawler_jobs/all", allow(crawlerReaders, getCrawlerJobs)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/app_review_crawler_jobs/all", allow(crawlerReaders, getAppReviewCrawlerJobs)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/recommendationTores/{codename}", allow(readers, getRecommendationTores)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/recommendation/{term}", allow(readers, getToreRecommendation)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/crawler/jobs", allow(crawlerReaders, getAllCrawlerJobs)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/crawler/jobs/{id}", allow(crawlerReaders, getCrawlerJob)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/crawler/jobs/{id}/runs", allow(crawlerReaders, getCrawlerJobRuns)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/crawler/jobs/{id}/runs/{run}", allow(crawlerReaders, getCrawlerJobRun)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/{crawler:reddit_crawler|app_review_crawler}/jobs/{job}/runs", allow(crawlerReaders, getCrawlerJobRuns)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/{crawler:reddit_crawler|app_review_crawler}/jobs/{job}/runs/{run}", allow(crawlerReaders, getCrawlerJobRun)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotationcodes/all", allow(readers, getAllCodesFromAnnotations)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotationcodes/concordance", allow(readers, getCodeConcordance)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/statistics/all", allow(readers, getAllAnnotationStatistics)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/statistics/name/{annotation}", allow(readers, getAnnotationStatistics)).Methods("GET")
+	router.HandleFunc("/hitec/repository/concepts/annotation/statistics/dataset/{dataset}", allow(readers, getDatasetAnnotationStatistics)).Methods("GET")
 
 	// Delete
-	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}", deleteDataset).Methods("DELETE")
-	router.HandleFunc("/hitec/repository/concepts/detection/result/{result}", deleteResult).Methods("DELETE")
-	router.HandleFunc("/hitec/repository/concepts/annotation/name/{annotation}", deleteAnnotation).Methods("DELETE")
-	router.HandleFunc("/hitec/repository/concepts/agreement/name/{agreement}", deleteAgreement).Methods("DELETE")
-	router.HandleFunc("/hitec/repository/concepts/store/reddit_crawler/jobs/{job}", deleteCrawlerJob).Methods("DELETE")
-	router.HandleFunc("/hitec/repository/concepts/store/app_review_crawler/jobs/{job}", deleteAppReviewCrawlerJob).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}", allow(admins, deleteDataset)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/groundtruth/{name}", allow(researchers, deleteGroundTruth)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/dataset/name/{dataset}/groundtruth/{name}/element/{id}", allow(researchers, deleteTruthElement)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/detection/result/{result}", allow(researchers, deleteResult)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/annotation/name/{annotation}", allow(researchers, deleteAnnotation)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/agreement/name/{agreement}", allow(researchers, deleteAgreement)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/store/crawler/jobs/{id}", allow(crawlers, deleteCrawlerJobById)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/store/reddit_crawler/jobs/{job}", allow(crawlers, deleteCrawlerJob)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/store/app_review_crawler/jobs/{job}", allow(crawlers, deleteAppReviewCrawlerJob)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/annotation/tores/category/{category}", allow(researchers, deleteToreCategory)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/annotation/relationships/type/{relationship}", allow(researchers, deleteRelationshipType)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/annotation/scheme/{scheme}", allow(admins, deleteAnnotationScheme)).Methods("DELETE")
+	router.HandleFunc("/hitec/repository/concepts/store/recommendations/{codename}", allow(researchers, deleteRecommendation)).Methods("DELETE")
 
 	// Update
-	router.HandleFunc("/hitec/repository/concepts/store/reddit_crawler/jobs/{job}", updateCrawlerJob).Methods("PUT")
-	router.HandleFunc("/hitec/repository/concepts/store/app_review_crawler/jobs/{job}", updateAppReviewCrawlerJob).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/reddit_crawler/jobs/{job}", allow(crawlers, updateCrawlerJob)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/app_review_crawler/jobs/{job}", allow(crawlers, updateAppReviewCrawlerJob)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/annotation/tores/category/{category}", allow(researchers, updateToreCategory)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/recommendations/{codename}", allow(researchers, putRecommendation)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/crawler/jobs/{id}/schedule", allow(crawlers, putCrawlerJobSchedule)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/{crawler:reddit_crawler|app_review_crawler}/jobs/{job}/schedule", allow(crawlers, putCrawlerJobSchedule)).Methods("PUT")
+	router.HandleFunc("/hitec/repository/concepts/store/crawler/jobs/{id}/runs/{run}", allow(crawlers, patchCrawlerJobRun)).Methods("PATCH")
+	router.HandleFunc("/hitec/repository/concepts/store/{crawler:reddit_crawler|app_review_crawler}/jobs/{job}/runs/{run}", allow(crawlers, patchCrawlerJobRun)).Methods("PATCH")
+	router.HandleFunc("/hitec/repository/concepts/store/recommendations/{codename}", allow(researchers, patchRecommendation)).Methods("PATCH")
 
 
 	return router
 }
 
+// postProject registers a project and initializes its database
+func postProject(w http.ResponseWriter, r *http.Request) {
+	var project Project
+	err := json.NewDecoder(r.Body).Decode(&project)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postProject - %s\n", project.Name)
+
+	project, errs := validateProject(project)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid project", errs)
+		return
+	}
+
+	m := mongoClient.Copy()
+	defer m.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	project.CreatedAt = time.Now()
+	err = MongoInsertProject(m.DB(database), project)
+	if mgo.IsDup(err) {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Project already exists", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("ERROR inserting project: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not create project", Status: false})
+		return
+	}
+	MongoInitializeProject(m.DB(projectDatabase(project.Name)))
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(project)
+}
+
+// getProjects returns all projects to admins and the crawler service, to other callers the projects they are members of
+func getProjects(w http.ResponseWriter, r *http.Request) {
+	principal := requestPrincipal(r)
+	fmt.Printf("REST call: getProjects - %s\n", principal.Name)
+
+	member := principal.Name
+	if principal.Role == roleAdmin || principal.Role == roleCrawlerService {
+		member = ""
+	}
+
+	m := mongoClient.Copy()
+	defer m.Close()
+	projects := MongoGetProjects(m.DB(database), member)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(projects)
+}
+
+// putProjectMember adds a member to a project
+func putProjectMember(w http.ResponseWriter, r *http.Request) {
+	setProjectMember(w, r, true)
+}
+
+// deleteProjectMember removes a member from a project
+func deleteProjectMember(w http.ResponseWriter, r *http.Request) {
+	setProjectMember(w, r, false)
+}
+
+func setProjectMember(w http.ResponseWriter, r *http.Request, isMember bool) {
+	params := mux.Vars(r)
+	project, member := params["project"], strings.TrimSpace(params["member"])
+	fmt.Printf("REST call: setProjectMember - %s %s %t\n", project, member, isMember)
+
+	if member == "" {
+		writeValidationErrors(w, "Invalid project member", []ValidationError{{Field: "member", Message: "member must not be empty"}})
+		return
+	}
+
+	m := mongoClient.Copy()
+	defer m.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	err := MongoSetProjectMember(m.DB(database), project, member, isMember)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Project does not exist", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("ERROR updating project members: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update project members", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Project members updated", Status: true})
+}
+
 func handleErrorWithRequest(err error, w http.ResponseWriter) {
 	if err != nil {
 		fmt.Printf("ERROR %s\n", err)
@@ -92,6 +282,31 @@ func handleErrorWithRequest(err error, w http.ResponseWriter) {
 	}
 }
 
+func writeValidationErrors(w http.ResponseWriter, message string, errs []ValidationError) {
+	fmt.Printf("ERROR validating request: %s %v\n", message, errs)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(ValidationResponse{Message: message, Status: false, Errors: errs})
+}
+
+// resolveScheme returns the scheme an annotation or agreement is bound to. New ones are bound to the requested scheme
+// or the default scheme, existing ones keep the scheme they were created with.
+func resolveScheme(m *mgo.Database, requested string, stored string, exists bool) (string, []ValidationError) {
+	if exists {
+		scheme := schemeOrDefault(stored)
+		if requested != "" && requested != scheme {
+			return scheme, []ValidationError{{Field: "scheme", Message: fmt.Sprintf("scheme can not be changed from %q after creation", scheme)}}
+		}
+		return scheme, nil
+	}
+
+	scheme := schemeOrDefault(requested)
+	if !MongoAnnotationSchemeExists(m, scheme) {
+		return scheme, []ValidationError{{Field: "scheme", Message: fmt.Sprintf("scheme %q does not exist", scheme)}}
+	}
+	return scheme, nil
+}
+
 //  store an existing annotation
 func postAnnotation(w http.ResponseWriter, r *http.Request) {
 	var annotation Annotation
@@ -105,9 +320,43 @@ func postAnnotation(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	// check the references within the annotation, repairing them if requested
+	var repairs []ValidationError
+	if r.URL.Query().Get("repair") == "true" {
+		annotation, repairs = repairAnnotation(annotation)
+	}
+	if errs := validateAnnotationStructure(annotation); len(errs) > 0 {
+		writeValidationErrors(w, "Annotation has invalid references or code counters", errs)
+		return
+	}
+
+	// annotators only save annotations assigned to them until they are reviewed, the assignment is kept as stored
+	stored, exists := MongoFindAnnotation(m, annotation.Name)
+	if err := checkAnnotationModification(r, annotation.Name, stored); err != nil {
+		writeForbidden(w, err.Error())
+		return
+	}
+	keepAnnotationAssignment(&annotation, stored)
+
+	// bind the annotation to its scheme and validate codes and relationships against it
+	scheme, errs := resolveScheme(m, annotation.Scheme, stored.Scheme, exists)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid annotation scheme", errs)
+		return
+	}
+	annotation.Scheme = scheme
+	startAnnotation(&annotation, stored, time.Now())
+	errs = validateAnnotationTores(annotation, MongoGetToreCategories(m, scheme), stored)
+	errs = append(errs, validateAnnotationRelationships(annotation, MongoGetRelationshipTypes(m, scheme))...)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Annotation violates the TORE taxonomy or relationship constraints", errs)
+		return
+	}
+
 	// insert data into the db
-	m := mongoClient.Copy()
-	defer m.Close()
 	err = MongoInsertAnnotation(m, annotation)
 	if err != nil {
 		fmt.Printf("ERROR %s\n", err)
@@ -116,8 +365,313 @@ func postAnnotation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// send response
+	if len(repairs) > 0 {
+		w.Header().Set(contentTypeKey, contentTypeValJSON)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ValidationResponse{Message: "Annotation repaired", Status: true, Errors: repairs})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+}
+
+// postInitializeAnnotation creates a new annotation for a dataset with the tokens of its documents. The annotation
+// shares the tokens of the other annotations of the dataset, so their token indices can be aligned.
+func postInitializeAnnotation(w http.ResponseWriter, r *http.Request) {
+	var request AnnotationInitRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postInitializeAnnotation - %s %s\n", request.Name, request.Dataset)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	var errs []ValidationError
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+	} else if _, exists := MongoFindAnnotation(m, request.Name); exists {
+		errs = append(errs, ValidationError{Field: "name", Message: fmt.Sprintf("annotation %q already exists", request.Name)})
+	}
+	dataset := MongoGetDataset(m, request.Dataset)
+	if request.Dataset == "" || dataset.Name != request.Dataset {
+		errs = append(errs, ValidationError{Field: "dataset", Message: fmt.Sprintf("dataset %q does not exist", request.Dataset)})
+	}
+	scheme, schemeErrs := resolveScheme(m, request.Scheme, "", false)
+	errs = append(errs, schemeErrs...)
+	language, languageErrs := resolveLanguage(request.Language)
+	errs = append(errs, languageErrs...)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid annotation", errs)
+		return
+	}
+
+	annotation := Annotation{
+		UploadedAt:        time.Now(),
+		Name:              request.Name,
+		Dataset:           dataset.Name,
+		Scheme:            scheme,
+		Codes:             []Code{},
+		TORERelationships: []TORERelationship{},
+	}
+	initializeAnnotationTokens(m, &annotation, dataset, tokenTaggers[language], request.SentenceTokenization)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	err = MongoInsertAnnotation(m, annotation)
+	if err != nil {
+		fmt.Printf("ERROR inserting annotation: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not create annotation", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(annotation)
+}
+
+// postCloneAnnotation creates a copy of an annotation under a new name, keeping all, none or the codes of selected
+// TORE categories, e.g. as identical starting point for the annotators of an agreement study
+func postCloneAnnotation(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["annotation"]
+	var request AnnotationCloneRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postCloneAnnotation - %s as %s\n", name, request.Name)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	source, exists := MongoFindAnnotation(m, name)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation does not exist", Status: false})
+		return
+	}
+
+	var errs []ValidationError
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+	} else if _, exists := MongoFindAnnotation(m, request.Name); exists {
+		errs = append(errs, ValidationError{Field: "name", Message: fmt.Sprintf("annotation %q already exists", request.Name)})
+	}
+	if source.Dataset == "" || MongoGetDataset(m, source.Dataset).Name != source.Dataset {
+		errs = append(errs, ValidationError{Field: "dataset", Message: fmt.Sprintf("dataset %q of the annotation does not exist", source.Dataset)})
+	}
+	if request.StripCodes && len(request.Tores) > 0 {
+		errs = append(errs, ValidationError{Field: "tores", Message: "tores can not be kept if codes are stripped"})
+	}
+	errs = append(errs, validateCloneTores(request.Tores, MongoGetToreCategories(m, schemeOrDefault(source.Scheme)))...)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid annotation clone", errs)
+		return
+	}
+
+	clone := cloneAnnotation(source, request, time.Now())
+	err = MongoInsertAnnotation(m, clone)
+	if err != nil {
+		fmt.Printf("ERROR inserting annotation: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not clone annotation", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(clone)
+}
+
+// putAnnotationAssignment assigns an annotation to an annotator and sets the status of the work on it. Assigning an
+// annotation without status marks it as not started.
+func putAnnotationAssignment(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["annotation"]
+	var assignment AnnotationAssignment
+	err := json.NewDecoder(r.Body).Decode(&assignment)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: putAnnotationAssignment - %s\n", name)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	stored, exists := MongoFindAnnotation(m, name)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation does not exist", Status: false})
+		return
+	}
+	if err := checkAnnotationModification(r, name, stored); err != nil {
+		writeForbidden(w, err.Error())
+		return
+	}
+	if requestPrincipal(r).Role == roleAnnotator && (assignment.Assignee != nil || (assignment.Status != annotationInProgress && assignment.Status != annotationSubmitted)) {
+		writeForbidden(w, "Annotators can only mark their annotations in progress or submitted")
+		return
+	}
+	assignment, errs := validateAnnotationAssignment(assignment, stored.Status)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid annotation assignment", errs)
+		return
+	}
+	if assignment.Status == "" && stored.Status == "" && assignment.Assignee != nil && *assignment.Assignee != "" {
+		assignment.Status = annotationNotStarted
+	}
+	if assignment.Status == stored.Status {
+		assignment.Status = ""
+	}
+
+	err = MongoUpdateAnnotationAssignment(m, name, assignment, time.Now())
+	if err != nil {
+		fmt.Printf("ERROR updating annotation assignment: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update annotation assignment", Status: false})
+		return
+	}
+	stored, _ = MongoFindAnnotation(m, name)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(annotationProgress(stored))
+}
+
+// putAnnotationDocumentDone marks a document of an annotation as done, e.g. if it has nothing to code
+func putAnnotationDocumentDone(w http.ResponseWriter, r *http.Request) {
+	setAnnotationDocumentDone(w, r, true)
+}
+
+// deleteAnnotationDocumentDone marks a document of an annotation as not done
+func deleteAnnotationDocumentDone(w http.ResponseWriter, r *http.Request) {
+	setAnnotationDocumentDone(w, r, false)
+}
+
+func setAnnotationDocumentDone(w http.ResponseWriter, r *http.Request, done bool) {
+	params := mux.Vars(r)
+	name, document := params["annotation"], params["document"]
+	fmt.Printf("REST call: setAnnotationDocumentDone - %s %s %t\n", name, document, done)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	stored, exists := MongoFindAnnotation(m, name)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation does not exist", Status: false})
+		return
+	}
+	if err := checkAnnotationModification(r, name, stored); err != nil {
+		writeForbidden(w, err.Error())
+		return
+	}
+	found := false
+	for _, doc := range stored.Docs {
+		found = found || doc.Name == document
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Document is not part of the annotation", Status: false})
+		return
+	}
+
+	err := MongoSetAnnotationDocumentDone(m, name, document, done)
+	if err != nil {
+		fmt.Printf("ERROR marking annotation document: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not mark annotation document", Status: false})
+		return
+	}
+	stored, _ = MongoFindAnnotation(m, name)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(annotationProgress(stored))
+}
+
+// getAnnotationProgress returns the progress of all annotations, optionally only of a dataset or an annotator
+func getAnnotationProgress(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: getAnnotationProgress\n")
+
+	query := bson.M{}
+	if dataset := r.URL.Query().Get("dataset"); dataset != "" {
+		query["dataset"] = dataset
+	}
+	if assignee := r.URL.Query().Get("assignee"); assignee != "" {
+		query[fieldAnnotationAssignee] = assignee
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	progress := []AnnotationProgress{}
+	for _, annotation := range MongoGetAnnotationsProgress(m, query) {
+		progress = append(progress, annotationProgress(annotation))
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(progress)
+}
+
+// getAnnotatorQueue returns the annotations an annotator still has to work on
+func getAnnotatorQueue(w http.ResponseWriter, r *http.Request) {
+	assignee := mux.Vars(r)["assignee"]
+	fmt.Printf("REST call: getAnnotatorQueue - %s\n", assignee)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	annotations := MongoGetAnnotationsProgress(m, bson.M{
+		fieldAnnotationAssignee: assignee,
+		fieldAnnotationStatus:   bson.M{"$nin": []string{annotationSubmitted, annotationReviewed}},
+	})
+
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(workQueue(annotations))
+}
+
+// initializeAnnotationTokens sets the documents and tokens of a new annotation. The tokens of the oldest tokenized
+// annotation of the dataset are reused as long as its documents are still the first documents of the dataset,
+// documents ingested since are tokenized and appended. It returns whether documents were tokenized.
+func initializeAnnotationTokens(m *mgo.Database, annotation *Annotation, dataset Dataset, tagger tokenTagger, sentences bool) bool {
+	tokenized := false
+	if stored, ok := MongoGetDatasetTokens(m, dataset.Name); ok && isDocumentsPrefix(stored.Docs, dataset.Documents) {
+		annotation.Docs = stored.Docs
+		annotation.Tokens = stored.Tokens
+		for i := range annotation.Tokens {
+			annotation.Tokens[i].NumNameCodes, annotation.Tokens[i].NumToreCodes = 0, 0
+		}
+		if len(dataset.Documents) > len(stored.Docs) {
+			docs, tokens := tokenizeDocuments(dataset.Documents[len(stored.Docs):], tagger)
+			annotation.Docs, annotation.Tokens = appendTokens(annotation.Docs, annotation.Tokens, docs, tokens)
+			tokenized = true
+		}
+	} else {
+		annotation.Docs, annotation.Tokens = tokenizeDocuments(dataset.Documents, tagger)
+		tokenized = true
+	}
+
+	annotation.SentenceTokenizationEnabledForAnnotation = sentences
+	annotation.Sentences = nil
+	if sentences {
+		annotation.Sentences = tokenSentences(annotation.Docs, annotation.Tokens)
+	}
+	return tokenized
+}
+
+// resolveLanguage returns the language of the tagger to tokenize with, the default language if none is requested
+func resolveLanguage(language string) (string, []ValidationError) {
+	if language == "" {
+		return defaultLanguage, nil
+	}
+	if _, ok := tokenTaggers[language]; !ok {
+		return language, []ValidationError{{Field: "language", Message: fmt.Sprintf("no tagger for language %q", language)}}
+	}
+	return language, nil
 }
 
 //  store an existing agreement
@@ -133,9 +687,19 @@ func postAgreement(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	// bind the agreement to its scheme
+	stored, exists := MongoFindAgreement(m, agreement.Name)
+	scheme, errs := resolveScheme(m, agreement.Scheme, stored.Scheme, exists)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid annotation scheme", errs)
+		return
+	}
+	agreement.Scheme = scheme
+
 	// insert data into the db
-	m := mongoClient.Copy()
-	defer m.Close()
 	err = MongoInsertAgreement(m, agreement)
 	if err != nil {
 		fmt.Printf("ERROR %s\n", err)
@@ -166,11 +730,29 @@ func postDataset(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	groundTruth := GroundTruth{Dataset: dataset.Name, Name: defaultGroundTruth, Elements: dataset.GroundTruth}
+	if errs := validateGroundTruth(groundTruth, dataset.Documents); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid ground truth", errs)
+		return
+	}
 
-	// insert data into the db
-	m := mongoClient.Copy()
-	defer m.Close()
+	// the other label sets of a re-posted dataset must still refer to its documents
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	if errs := validateStoredGroundTruths(MongoGetGroundTruths(m, dataset.Name), dataset.Documents); len(errs) > 0 {
+		writeValidationErrors(w, "Label sets refer to documents that are not in the dataset", errs)
+		return
+	}
+
+	// insert data into the db, the default label set follows the ground truth of the dataset
 	err = MongoInsertDataset(m, dataset)
+	if err == nil && len(dataset.GroundTruth) > 0 {
+		err = MongoSaveGroundTruth(m, groundTruth)
+	} else if err == nil {
+		if err = MongoDeleteGroundTruth(m, dataset.Name, defaultGroundTruth); err == mgo.ErrNotFound {
+			err = nil
+		}
+	}
 	handleErrorWithRequest(err, w)
 
 	// send response
@@ -178,6 +760,72 @@ func postDataset(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 }
 
+// postDatasetIngest appends crawled documents to a dataset, documents that are already in the dataset are skipped
+func postDatasetIngest(w http.ResponseWriter, r *http.Request) {
+	datasetName := mux.Vars(r)["dataset"]
+	fmt.Printf("REST call: postDatasetIngest - %s\n", datasetName)
+
+	var ingest DatasetIngest
+	err := json.NewDecoder(r.Body).Decode(&ingest)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var errs []ValidationError
+	if len(ingest.Documents) == 0 {
+		errs = append(errs, ValidationError{Field: "documents", Message: "at least one document must be given"})
+	}
+	for i, document := range ingest.Documents {
+		if err := document.validate(); err != nil {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("documents[%d].text", i), Message: "text must not be empty"})
+		}
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	if ingest.CrawlerRunId != "" {
+		if _, err := MongoGetCrawlerJobRun(m, ingest.CrawlerJobId, ingest.CrawlerRunId); err != nil {
+			errs = append(errs, ValidationError{Field: "crawler_run_id", Message: "the run does not exist for the crawler job"})
+		}
+	} else if ingest.CrawlerJobId != "" {
+		if _, err := MongoGetCrawlerJob(m, ingest.CrawlerJobId); err != nil {
+			errs = append(errs, ValidationError{Field: "crawler_job_id", Message: "the crawler job does not exist"})
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid ingest", errs)
+		return
+	}
+
+	record, err := MongoIngestDocuments(m, datasetName, ingest)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR ingesting documents: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not ingest documents", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(record)
+}
+
+// getDatasetIngests returns the ingest history of a dataset
+func getDatasetIngests(w http.ResponseWriter, r *http.Request) {
+	datasetName := mux.Vars(r)["dataset"]
+	fmt.Printf("REST call: getDatasetIngests - %s\n", datasetName)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ingests := MongoGetDatasetIngests(m, datasetName)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ingests)
+}
+
 func postDetectionResult(w http.ResponseWriter, r *http.Request) {
 
 	// parse request
@@ -201,10 +849,28 @@ func postDetectionResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// insert data into the db
-	m := mongoClient.Copy()
-	defer m.Close()
-	err = MongoInsertResult(m, result)
+	// the status of a stored result can only move along the lifecycle
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	stored := MongoGetResult(m, result.StartedAt)
+	result, errs := applyResultStatus(result, stored, stored.Method == result.Method, time.Now())
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid result status", errs)
+		return
+	}
+
+	// insert data into the db
+	if stored.Method == result.Method {
+		err = MongoReplaceResult(m, result, stored)
+		if err == mgo.ErrNotFound {
+			w.Header().Set(contentTypeKey, contentTypeValJSON)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result was changed concurrently", Status: false})
+			return
+		}
+	} else {
+		err = MongoInsertResult(m, result)
+	}
 	handleErrorWithRequest(err, w)
 
 	// send response
@@ -212,7 +878,11 @@ func postDetectionResult(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 }
 
+// postUpdateResultName changes the name of a result.
+//
+// Deprecated: clients use patchResult, the route is kept for older clients and answers with a Deprecation header.
 func postUpdateResultName(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Deprecation", "true")
 
 	// parse request
 	var result Result
@@ -226,11 +896,11 @@ func postUpdateResultName(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("postUpdateResultName called. Name: %s, Time: %s \n", result.Name, result.StartedAt)
 
 	// retrieve result
-	m := mongoClient.Copy()
-	defer m.Close()
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	res := MongoGetResult(m, result.StartedAt)
 
-	if res.Status != "finished" && res.Status != "failed" {
+	if !isFinalResultStatus(res.Status) {
 		fmt.Printf("ERROR: can not change name for result with status: %s\n", res.Status)
 		w.WriteHeader(http.StatusBadRequest)
 		return
@@ -261,8 +931,8 @@ func postAddGroundTruth(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("postAddGroundTruth called. Dataset Name: %s. \n", dataset.Name)
 
 	// retrieve dataset
-	m := mongoClient.Copy()
-	defer m.Close()
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	data := MongoGetDataset(m, dataset.Name)
 
 	if data.Name != dataset.Name {
@@ -276,10 +946,14 @@ func postAddGroundTruth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data.GroundTruth = dataset.GroundTruth
+	// the ground truth of the dataset is its default label set
+	groundTruth := GroundTruth{Dataset: data.Name, Name: defaultGroundTruth, Elements: dataset.GroundTruth}
+	if errs := validateGroundTruth(groundTruth, data.Documents); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid ground truth", errs)
+		return
+	}
 
-	// insert updated result
-	err = MongoInsertDataset(m, data)
+	err = MongoSaveGroundTruth(m, groundTruth)
 	handleErrorWithRequest(err, w)
 
 	// send response
@@ -287,623 +961,2198 @@ func postAddGroundTruth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 }
 
-func getDataset(w http.ResponseWriter, r *http.Request) {
-	// get request param
-	params := mux.Vars(r)
-	datasetName := params["dataset"]
+// findRouteDataset returns the dataset of the route or writes a not found response
+func findRouteDataset(w http.ResponseWriter, m *mgo.Database, name string) (Dataset, bool) {
+	dataset := MongoGetDataset(m, name)
+	if name == "" || dataset.Name != name {
+		w.Header().Set(contentTypeKey, contentTypeValJSON)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Dataset does not exist", Status: false})
+		return dataset, false
+	}
+	return dataset, true
+}
 
-	fmt.Println("REST call: getDataset, params: ", datasetName)
+// writeGroundTruthNotFound writes the response for a missing label set or truth element
+func writeGroundTruthNotFound(w http.ResponseWriter, message string) {
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusNotFound)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: message, Status: false})
+}
 
-	// retrieve data from dataset
-	m := mongoClient.Copy()
-	defer m.Close()
-	dataset := MongoGetDataset(m, datasetName)
+// getGroundTruths returns all label sets of a dataset
+func getGroundTruths(w http.ResponseWriter, r *http.Request) {
+	datasetName := mux.Vars(r)["dataset"]
+	fmt.Printf("REST call: getGroundTruths - %s\n", datasetName)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	groundTruths := MongoGetGroundTruths(m, datasetName)
 
-	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(dataset)
+	_ = json.NewEncoder(w).Encode(groundTruths)
 }
 
-func postAllToreTypes(w http.ResponseWriter, r *http.Request) {
+// getGroundTruth returns a label set of a dataset
+func getGroundTruth(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: getGroundTruth - %s %s\n", params["dataset"], params["name"])
 
-	fmt.Println("postAllToreTypes")
-	m := mongoClient.Copy()
-	defer m.Close()
-	var body = bson.M{fieldToreTypes: new([]string)}
-	err := json.NewDecoder(r.Body).Decode(&body)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	groundTruth, err := MongoGetGroundTruth(m, params["dataset"], params["name"])
 	if err != nil {
-		fmt.Printf("Error decoding request: %v\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeGroundTruthNotFound(w, "Ground truth does not exist")
 		return
 	}
-	fmt.Printf("Got body: %v\n", body)
-	var names []string
-	for _, value := range body[fieldToreTypes].([]interface{}) {
-		fmt.Printf("element: %v\n", value)
-		names = append(names, value.(string))
-	}
 
-	err = MongoPostAllTORE(m, names)
-	if err != nil {
-		fmt.Printf("Error posting all tore types: %v\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
-	} else {
-		w.WriteHeader(http.StatusOK)
-	}
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(groundTruth)
 }
 
-func getAllToreTypes(w http.ResponseWriter, r *http.Request) {
+// getGroundTruthCoverage reports which documents of the dataset have a value in the label set
+func getGroundTruthCoverage(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: getGroundTruthCoverage - %s %s\n", params["dataset"], params["name"])
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	names := MongoGetAllTORE(m)
-	if names == nil {
-		w.WriteHeader(http.StatusInternalServerError)
-	} else {
-		_ = json.NewEncoder(w).Encode(bson.M{"tores": names})
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	dataset, ok := findRouteDataset(w, m, params["dataset"])
+	if !ok {
+		return
+	}
+	groundTruth, err := MongoGetGroundTruth(m, dataset.Name, params["name"])
+	if err != nil {
+		writeGroundTruthNotFound(w, "Ground truth does not exist")
+		return
 	}
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(groundTruthCoverage(groundTruth, dataset.Documents))
 }
 
-func postAllRelationshipNames(w http.ResponseWriter, r *http.Request) {
+// postGroundTruth creates or replaces a label set of a dataset
+func postGroundTruth(w http.ResponseWriter, r *http.Request) {
+	datasetName := mux.Vars(r)["dataset"]
+	fmt.Printf("REST call: postGroundTruth - %s\n", datasetName)
 
-	fmt.Println("postAllRelationshipNames")
-	m := mongoClient.Copy()
-	defer m.Close()
-	var body = bson.M{fieldRelationshipNames: new([]string), "owners": new([]string)}
-	err := json.NewDecoder(r.Body).Decode(&body)
+	var groundTruth GroundTruth
+	err := json.NewDecoder(r.Body).Decode(&groundTruth)
 	if err != nil {
-		fmt.Printf("Error decoding request: %v\n", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	fmt.Printf("Got body: %v\n", body)
-	var names []string
-	var owners []string
 
-	var bodyOwners = body["owners"].([]interface{})
-
-	for index, value := range body[fieldRelationshipNames].([]interface{}) {
-		fmt.Printf("element: %v\n", value)
-		names = append(names, value.(string))
-		owners = append(owners, bodyOwners[index].(string))
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	dataset, ok := findRouteDataset(w, m, datasetName)
+	if !ok {
+		return
+	}
+	groundTruth.Dataset = dataset.Name
+	if errs := validateGroundTruth(groundTruth, dataset.Documents); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid ground truth", errs)
+		return
 	}
 
-	err = MongoPostAllRelationshipNames(m, names, owners)
+	err = MongoSaveGroundTruth(m, groundTruth)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	if err != nil {
-		fmt.Printf("Error posting all relationship names: %v\n", err)
+		fmt.Printf("ERROR saving ground truth: %s\n", err)
 		w.WriteHeader(http.StatusInternalServerError)
-	} else {
-		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not save ground truth", Status: false})
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Ground truth saved", Status: true})
 }
 
-func getAllRelationshipNames(w http.ResponseWriter, r *http.Request) {
+// deleteGroundTruth removes a label set of a dataset
+func deleteGroundTruth(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: deleteGroundTruth - %s %s\n", params["dataset"], params["name"])
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	names, owners := MongoGetAllRelationshipNames(m)
-	if names == nil {
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	err := MongoDeleteGroundTruth(m, params["dataset"], params["name"])
+	if err == mgo.ErrNotFound {
+		writeGroundTruthNotFound(w, "Ground truth does not exist")
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR deleting ground truth: %s\n", err)
 		w.WriteHeader(http.StatusInternalServerError)
-	} else {
-		_ = json.NewEncoder(w).Encode(bson.M{"relationship_names": names, "owners": owners})
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete ground truth", Status: false})
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Ground truth deleted", Status: true})
 }
 
-// getAnnotation return the annotation with a given name
-func getAnnotation(w http.ResponseWriter, r *http.Request) {
-	// get request param
+// putTruthElement sets the truth value of a document in a label set
+func putTruthElement(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	annotationName := params["annotation"]
+	fmt.Printf("REST call: putTruthElement - %s %s %s\n", params["dataset"], params["name"], params["id"])
 
-	fmt.Println("REST call: getAnnotation, params: " + annotationName)
+	var element TruthElement
+	err := json.NewDecoder(r.Body).Decode(&element)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	element.Id = params["id"]
 
-	// retrieve data from dataset
-	m := mongoClient.Copy()
-	defer m.Close()
-	annotation := MongoGetAnnotation(m, annotationName)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	dataset, ok := findRouteDataset(w, m, params["dataset"])
+	if !ok {
+		return
+	}
+	if !documentIds(dataset.Documents)[element.Id] {
+		writeValidationErrors(w, "Invalid truth element", []ValidationError{{Field: "id", Message: fmt.Sprintf("%q is not a document of the dataset", element.Id)}})
+		return
+	}
 
-	// write the response
+	err = MongoSetTruthElement(m, dataset.Name, params["name"], element)
+	if err == mgo.ErrNotFound {
+		writeGroundTruthNotFound(w, "Ground truth does not exist")
+		return
+	}
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR setting truth element: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not set truth element", Status: false})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(annotation)
+	_ = json.NewEncoder(w).Encode(element)
 }
 
-// getAgreement return the agreement with a given name
-func getAgreement(w http.ResponseWriter, r *http.Request) {
-	// get request param
+// deleteTruthElement removes the truth value of a document from a label set
+func deleteTruthElement(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	agreementName := params["agreement"]
-
-	fmt.Println("REST call: getAgreement, params: " + agreementName)
+	fmt.Printf("REST call: deleteTruthElement - %s %s %s\n", params["dataset"], params["name"], params["id"])
 
-	// retrieve data from dataset
-	m := mongoClient.Copy()
-	defer m.Close()
-	agreement := MongoGetAgreement(m, agreementName)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	err := MongoRemoveTruthElement(m, params["dataset"], params["name"], params["id"])
+	if err == mgo.ErrNotFound {
+		writeGroundTruthNotFound(w, "Truth element does not exist")
+		return
+	}
 
-	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR removing truth element: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not remove truth element", Status: false})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(agreement)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Truth element removed", Status: true})
 }
 
-// getAnnotationsForDataset return all annotations for a given dataset
-func getAnnotationsForDataset(w http.ResponseWriter, r *http.Request) {
+func getDataset(w http.ResponseWriter, r *http.Request) {
 	// get request param
 	params := mux.Vars(r)
-	dataset := params["dataset"]
+	datasetName := params["dataset"]
 
-	fmt.Println("REST call: getAnnotationsForDataset, params: " + dataset)
+	fmt.Println("REST call: getDataset, params: ", datasetName)
 
 	// retrieve data from dataset
-	m := mongoClient.Copy()
-	defer m.Close()
-	annotations := MongoGetAnnotationsForDataset(m, dataset)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	dataset := MongoGetDataset(m, datasetName)
 
 	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(annotations)
+	_ = json.NewEncoder(w).Encode(dataset)
 }
 
-func getAllAnnotations(w http.ResponseWriter, _ *http.Request) {
-
-	fmt.Printf("REST call: getAllAnnotations\n")
-
-	// retrieve all dataset names
-	m := mongoClient.Copy()
-	defer m.Close()
-	annotations := MongoGetAllAnnotations(m)
+func postAllToreTypes(w http.ResponseWriter, r *http.Request) {
 
-	// write the response
-	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(annotations)
+	fmt.Println("postAllToreTypes")
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	var body = bson.M{fieldToreTypes: new([]string)}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		fmt.Printf("Error decoding request: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	fmt.Printf("Got body: %v\n", body)
+	var names []string
+	for _, value := range body[fieldToreTypes].([]interface{}) {
+		fmt.Printf("element: %v\n", value)
+		names = append(names, value.(string))
+	}
 
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+	if !MongoAnnotationSchemeExists(m, scheme) {
+		writeValidationErrors(w, "Invalid TORE types", []ValidationError{{Field: "scheme", Message: fmt.Sprintf("scheme %q does not exist", scheme)}})
+		return
+	}
+	err = MongoPostAllTORE(m, scheme, names)
+	if err != nil {
+		fmt.Printf("Error posting all tore types: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
 }
 
-func getAllAgreements(w http.ResponseWriter, _ *http.Request) {
-
-	fmt.Printf("REST call: getAllAgreements\n")
-
-	// retrieve all dataset names
-	m := mongoClient.Copy()
-	defer m.Close()
-	agreements := MongoGetAllAgreements(m)
+func getAllToreTypes(w http.ResponseWriter, r *http.Request) {
 
-	// write the response
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(agreements)
-
+	if !MongoAnnotationSchemeExists(m, scheme) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Annotation scheme %s does not exist", scheme), Status: false})
+		return
+	}
+	names, err := MongoGetAllTORE(m, scheme)
+	if err != nil {
+		fmt.Printf("Error getting tore types: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		_ = json.NewEncoder(w).Encode(bson.M{"tores": names})
+	}
 }
 
-func getAllDatasets(w http.ResponseWriter, _ *http.Request) {
+// getToreCategories returns all tore categories of a scheme including deprecated ones
+func getToreCategories(w http.ResponseWriter, r *http.Request) {
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+	fmt.Printf("REST call: getToreCategories, scheme: %s\n", scheme)
 
-	fmt.Printf("REST call: getAllDatasets\n")
-
-	// retrieve all dataset names
-	m := mongoClient.Copy()
-	defer m.Close()
-	datasets := MongoGetAllDatasets(m)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	categories := MongoGetToreCategories(m, scheme)
 
 	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(datasets)
-
+	_ = json.NewEncoder(w).Encode(categories)
 }
 
-func getAllDetectionResults(w http.ResponseWriter, _ *http.Request) {
+// postToreCategory creates or updates a tore category
+func postToreCategory(w http.ResponseWriter, r *http.Request) {
+	var category ToreCategory
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postToreCategory, category: %s\n", category.Name)
 
-	fmt.Printf("REST call: getAllDetectionResults\n")
+	category = withToreCategoryDefaults(category)
+	if errs := validateToreCategory(category); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid TORE category", errs)
+		return
+	}
 
-	// retrieve all Results
-	m := mongoClient.Copy()
-	defer m.Close()
-	results := MongoGetAllResults(m)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	if !MongoAnnotationSchemeExists(m, category.Scheme) {
+		writeValidationErrors(w, "Invalid TORE category", []ValidationError{{Field: "scheme", Message: fmt.Sprintf("scheme %q does not exist", category.Scheme)}})
+		return
+	}
+	err = MongoInsertToreCategory(m, category)
+	handleErrorWithRequest(err, w)
 
-	// write the response
+	// send response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(results)
-
+	_ = json.NewEncoder(w).Encode(category)
 }
 
-func deleteAnnotation(w http.ResponseWriter, r *http.Request) {
+// updateToreCategory updates a tore category, renaming it rewrites the tore of all existing codes
+func updateToreCategory(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["category"]
 
-	params := mux.Vars(r)
-	annotationName := params["annotation"]
+	var category ToreCategory
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: updateToreCategory, category: %s, new name: %s\n", name, category.Name)
 
-	fmt.Printf("REST call: deleteAnnotation - %s\n", annotationName)
+	if category.Name == "" {
+		category.Name = name
+	}
+	if category.Scheme == "" {
+		category.Scheme = r.URL.Query().Get("scheme")
+	}
+	category = withToreCategoryDefaults(category)
+	if errs := validateToreCategory(category); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid TORE category", errs)
+		return
+	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	err := MongoDeleteAnnotation(m, annotationName)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if _, err := MongoGetToreCategory(m, category.Scheme, name); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "TORE category does not exist", Status: false})
+		return
+	}
+	if category.Name != name {
+		if _, err := MongoGetToreCategory(m, category.Scheme, category.Name); err == nil {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "A TORE category with this name already exists", Status: false})
+			return
+		}
+	}
+
+	err = MongoRenameToreCategory(m, name, category)
+	if err != nil {
+		fmt.Printf("ERROR updating tore category: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update TORE category", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(category)
+}
+
+// deleteToreCategory deletes a tore category that is not used by any code
+func deleteToreCategory(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["category"]
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+
+	fmt.Printf("REST call: deleteToreCategory - %s\n", name)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	usage, err := MongoCountToreCategoryUsage(m, scheme, name)
+	if err != nil {
+		fmt.Printf("error counting tore category usage: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete TORE category", Status: false})
+		return
+	}
+	if usage > 0 {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("TORE category is still used in %d annotations or agreements, deprecate it instead", usage), Status: false})
+		return
+	}
+
+	err = MongoDeleteToreCategory(m, scheme, name)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "TORE category does not exist", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("error deleting tore category: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete TORE category", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "TORE category successfully deleted", Status: true})
+}
+
+func withToreCategoryDefaults(category ToreCategory) ToreCategory {
+	category.Scheme = schemeOrDefault(category.Scheme)
+	if category.State == "" {
+		category.State = toreCategoryActive
+	}
+	if category.Level == "" {
+		category.Level = defaultToreLevels[category.Name]
+	}
+	return category
+}
+
+func postAllRelationshipNames(w http.ResponseWriter, r *http.Request) {
+
+	fmt.Println("postAllRelationshipNames")
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	var body struct {
+		Names  []string `json:"relationship_names"`
+		Owners []string `json:"owners"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		fmt.Printf("Error decoding request: %v\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("Got body: %v\n", body)
+
+	if len(body.Names) != len(body.Owners) {
+		w.Header().Set(contentTypeKey, contentTypeValJSON)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Got %d relationship names but %d owners", len(body.Names), len(body.Owners)), Status: false})
+		return
+	}
+
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+	if !MongoAnnotationSchemeExists(m, scheme) {
+		writeValidationErrors(w, "Invalid relationship names", []ValidationError{{Field: "scheme", Message: fmt.Sprintf("scheme %q does not exist", scheme)}})
+		return
+	}
+
+	// relationship types missing from the list are removed, which is only allowed while no annotation uses them
+	existing, _, err := MongoGetAllRelationshipNames(m, scheme)
+	if err != nil {
+		fmt.Printf("error getting relationship names: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	for _, name := range existing {
+		if containsString(body.Names, name) {
+			continue
+		}
+		usage, err := MongoCountRelationshipTypeUsage(m, scheme, name)
+		if err != nil {
+			fmt.Printf("error counting relationship type usage: %s\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if usage > 0 {
+			w.Header().Set(contentTypeKey, contentTypeValJSON)
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Relationship type %s is still used in %d annotations", name, usage), Status: false})
+			return
+		}
+	}
+
+	err = MongoPostAllRelationshipNames(m, scheme, body.Names, body.Owners)
+	if err != nil {
+		fmt.Printf("Error posting all relationship names: %v\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func getAllRelationshipNames(w http.ResponseWriter, r *http.Request) {
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	names, owners, err := MongoGetAllRelationshipNames(m, schemeOrDefault(r.URL.Query().Get("scheme")))
+	if err != nil {
+		fmt.Printf("error getting relationship names: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		_ = json.NewEncoder(w).Encode(bson.M{"relationship_names": names, "owners": owners})
+	}
+}
+
+// getRelationshipTypes returns all relationship types of a scheme with their tore constraints
+func getRelationshipTypes(w http.ResponseWriter, r *http.Request) {
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+	fmt.Printf("REST call: getRelationshipTypes, scheme: %s\n", scheme)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	relationshipTypes := MongoGetRelationshipTypes(m, scheme)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(relationshipTypes)
+}
+
+// postRelationshipType creates or updates a relationship type
+func postRelationshipType(w http.ResponseWriter, r *http.Request) {
+	var relationshipType RelationshipType
+	err := json.NewDecoder(r.Body).Decode(&relationshipType)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postRelationshipType, relationship type: %s\n", relationshipType.Name)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	relationshipType.Scheme = schemeOrDefault(relationshipType.Scheme)
+	if !MongoAnnotationSchemeExists(m, relationshipType.Scheme) {
+		writeValidationErrors(w, "Invalid relationship type", []ValidationError{{Field: "scheme", Message: fmt.Sprintf("scheme %q does not exist", relationshipType.Scheme)}})
+		return
+	}
+	if errs := validateRelationshipType(relationshipType, MongoGetToreCategories(m, relationshipType.Scheme)); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid relationship type", errs)
+		return
+	}
+
+	err = MongoInsertRelationshipType(m, relationshipType)
+	handleErrorWithRequest(err, w)
+
+	// send response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(relationshipType)
+}
+
+// deleteRelationshipType deletes a relationship type that is not used by any annotation
+func deleteRelationshipType(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["relationship"]
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+
+	fmt.Printf("REST call: deleteRelationshipType - %s\n", name)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	usage, err := MongoCountRelationshipTypeUsage(m, scheme, name)
+	if err != nil {
+		fmt.Printf("error counting relationship type usage: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete relationship type", Status: false})
+		return
+	}
+	if usage > 0 {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Relationship type is still used in %d annotations", usage), Status: false})
+		return
+	}
+
+	err = MongoDeleteRelationshipType(m, scheme, name)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Relationship type does not exist", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("error deleting relationship type: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete relationship type", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Relationship type successfully deleted", Status: true})
+}
+
+// getAnnotationSchemes returns all annotation schemes
+func getAnnotationSchemes(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: getAnnotationSchemes\n")
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	schemes := MongoGetAnnotationSchemes(m)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(schemes)
+}
+
+// postAnnotationScheme creates an annotation scheme or updates its description
+func postAnnotationScheme(w http.ResponseWriter, r *http.Request) {
+	var scheme AnnotationScheme
+	err := json.NewDecoder(r.Body).Decode(&scheme)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postAnnotationScheme, scheme: %s\n", scheme.Name)
+
+	if err := scheme.validate(); err != nil {
+		writeValidationErrors(w, "Invalid annotation scheme", []ValidationError{{Field: "name", Message: "name must not be empty"}})
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	err = MongoInsertAnnotationScheme(m, scheme)
+	handleErrorWithRequest(err, w)
+
+	// send response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteAnnotationScheme deletes a scheme with its categories and relationship types if no annotation is bound to it
+func deleteAnnotationScheme(w http.ResponseWriter, r *http.Request) {
+	scheme := mux.Vars(r)["scheme"]
+
+	fmt.Printf("REST call: deleteAnnotationScheme - %s\n", scheme)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if scheme == defaultScheme {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "The default scheme can not be deleted", Status: false})
+		return
+	}
+	usage, err := MongoCountAnnotationSchemeUsage(m, scheme)
+	if err != nil {
+		fmt.Printf("error counting annotation scheme usage: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete annotation scheme", Status: false})
+		return
+	}
+	if usage > 0 {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Annotation scheme is still used by %d annotations or agreements", usage), Status: false})
+		return
+	}
+
+	err = MongoDeleteAnnotationScheme(m, scheme)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation scheme does not exist", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("error deleting annotation scheme: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete annotation scheme", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation scheme successfully deleted", Status: true})
+}
+
+// getAnnotation return the annotation with a given name
+func getAnnotation(w http.ResponseWriter, r *http.Request) {
+	// get request param
+	params := mux.Vars(r)
+	annotationName := params["annotation"]
+
+	fmt.Println("REST call: getAnnotation, params: " + annotationName)
+
+	// retrieve data from dataset
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	annotation := MongoGetAnnotation(m, annotationName)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(annotation)
+}
+
+// getAgreement return the agreement with a given name
+func getAgreement(w http.ResponseWriter, r *http.Request) {
+	// get request param
+	params := mux.Vars(r)
+	agreementName := params["agreement"]
+
+	fmt.Println("REST call: getAgreement, params: " + agreementName)
+
+	// retrieve data from dataset
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	agreement := MongoGetAgreement(m, agreementName)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(agreement)
+}
+
+// getAnnotationsForDataset return all annotations for a given dataset
+func getAnnotationsForDataset(w http.ResponseWriter, r *http.Request) {
+	// get request param
+	params := mux.Vars(r)
+	dataset := params["dataset"]
+
+	fmt.Println("REST call: getAnnotationsForDataset, params: " + dataset)
+
+	// retrieve data from dataset
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	annotations := MongoGetAnnotationsForDataset(m, dataset)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(annotations)
+}
+
+func getAllAnnotations(w http.ResponseWriter, r *http.Request) {
+
+	fmt.Printf("REST call: getAllAnnotations\n")
+
+	// retrieve all dataset names
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	annotations := MongoGetAllAnnotations(m)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(annotations)
+
+}
+
+func getAllAgreements(w http.ResponseWriter, r *http.Request) {
+
+	fmt.Printf("REST call: getAllAgreements\n")
+
+	// retrieve all dataset names
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	agreements := MongoGetAllAgreements(m)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(agreements)
+
+}
+
+func getAllDatasets(w http.ResponseWriter, r *http.Request) {
+
+	fmt.Printf("REST call: getAllDatasets\n")
+
+	// retrieve all dataset names
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	datasets := MongoGetAllDatasets(m)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(datasets)
+
+}
+
+func getAllDetectionResults(w http.ResponseWriter, r *http.Request) {
+	tags := r.URL.Query()["tag"]
+	pinnedOnly := r.URL.Query().Get("pinned") == "true"
+
+	fmt.Printf("REST call: getAllDetectionResults - tags: %v, pinned: %t\n", tags, pinnedOnly)
+
+	// retrieve all Results having the tags
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	results := MongoFindResults(m, tags, pinnedOnly)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(results)
+
+}
+
+func deleteAnnotation(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	annotationName := params["annotation"]
+
+	fmt.Printf("REST call: deleteAnnotation - %s\n", annotationName)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	err := MongoDeleteAnnotation(m, annotationName)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation successfully deleted", Status: true})
+		return
+	} else {
+		fmt.Printf("error deleting annotation: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete annotation", Status: false})
+	}
+}
+
+func deleteAgreement(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	agreementName := params["agreement"]
+
+	fmt.Printf("REST call: deleteAgreement - %s\n", agreementName)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	err := MongoDeleteAgreement(m, agreementName)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Agreement successfully deleted", Status: true})
+		return
+	} else {
+		fmt.Printf("error deleting agreement: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete agreement", Status: false})
+	}
+}
+
+func deleteDataset(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	dataset := params["dataset"]
+
+	fmt.Printf("REST call: deleteDataset - %s\n", dataset)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ok := MongoDeleteDataset(m, dataset)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if ok {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Dataset successfully deleted", Status: true})
+		return
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete dataset", Status: false})
+	}
+}
+
+// parseResultDate parses the start time identifying a result in a route
+func parseResultDate(value string) (time.Time, error) {
+	var t Date
+	err := json.NewDecoder(strings.NewReader("{\"date\": \"" + value + "\"}")).Decode(&t)
+	return t.Date, err
+}
+
+// patchResult updates the name, tags, notes and pinned flag of a result
+func patchResult(w http.ResponseWriter, r *http.Request) {
+	result := mux.Vars(r)["result"]
+	var patch ResultPatch
+	err := json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: patchResult - %s\n", result)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	startedAt, err := parseResultDate(result)
+	if err != nil {
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, result)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	res := MongoGetResult(m, startedAt)
+	if res.Method == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result does not exist", Status: false})
+		return
+	}
+	patch, errs := validateResultPatch(patch, res.Status)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid result update", errs)
+		return
+	}
+
+	err = MongoUpdateResult(m, res.StartedAt, patch)
+	if err != nil {
+		fmt.Printf("ERROR updating result: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update result", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(MongoGetResult(m, res.StartedAt))
+}
+
+// postResultAnnotation creates a new annotation of the dataset of a result with the codes of the result as
+// machine-suggested codes. The annotation shares the tokens of the other annotations of the dataset, the dataset is
+// tokenized if it has none.
+func postResultAnnotation(w http.ResponseWriter, r *http.Request) {
+	result := mux.Vars(r)["result"]
+	var request AnnotationDraftRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postResultAnnotation - %s %s\n", result, request.Name)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	startedAt, err := parseResultDate(result)
+	if err != nil {
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, result)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	res := MongoGetResult(m, startedAt)
+	if res.Method == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result does not exist", Status: false})
+		return
+	}
+	dataset, ok := findRouteDataset(w, m, res.DatasetName)
+	if !ok {
+		return
+	}
+
+	var errs []ValidationError
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		errs = append(errs, ValidationError{Field: "name", Message: "name must not be empty"})
+	} else if _, exists := MongoFindAnnotation(m, request.Name); exists {
+		errs = append(errs, ValidationError{Field: "name", Message: fmt.Sprintf("annotation %q already exists", request.Name)})
+	}
+	if len(res.Codes) == 0 {
+		errs = append(errs, ValidationError{Field: "codes", Message: "result has no codes"})
+	}
+	scheme, schemeErrs := resolveScheme(m, request.Scheme, "", false)
+	errs = append(errs, schemeErrs...)
+	if len(schemeErrs) == 0 {
+		errs = append(errs, validateAnnotationTores(Annotation{Codes: res.Codes}, MongoGetToreCategories(m, scheme), Annotation{})...)
+	}
+	language, languageErrs := resolveLanguage(request.Language)
+	errs = append(errs, languageErrs...)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid annotation draft", errs)
+		return
+	}
+
+	now := time.Now()
+	annotation := Annotation{
+		UploadedAt:        now,
+		Name:              request.Name,
+		Dataset:           dataset.Name,
+		Scheme:            scheme,
+		Codes:             []Code{},
+		TORERelationships: []TORERelationship{},
+		SourceResult:      &res.StartedAt,
+	}
+	draft := AnnotationDraft{Annotation: annotation.Name, Dataset: dataset.Name}
+	draft.Tokenized = initializeAnnotationTokens(m, &annotation, dataset, tokenTaggers[language], request.SentenceTokenization)
+	draft.Unmapped = suggestCodes(&annotation, res.Codes)
+	if draft.Unmapped == nil {
+		draft.Unmapped = []string{}
+	}
+	draft.Suggested = len(annotation.Codes)
+
+	err = MongoInsertAnnotation(m, annotation)
+	if err != nil {
+		fmt.Printf("ERROR inserting annotation draft: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not create annotation", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(draft)
+}
+
+// postResultStatus moves a result to another status of its lifecycle
+func postResultStatus(w http.ResponseWriter, r *http.Request) {
+	result := mux.Vars(r)["result"]
+	var change ResultStatusChange
+	err := json.NewDecoder(r.Body).Decode(&change)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postResultStatus - %s %s\n", result, change.Status)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	startedAt, err := parseResultDate(result)
+	if err != nil {
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, result)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	res := MongoGetResult(m, startedAt)
+	if res.Method == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result does not exist", Status: false})
+		return
+	}
+	if change.Status == res.Status {
+		writeValidationErrors(w, "Invalid status change", []ValidationError{{Field: "status", Message: fmt.Sprintf("result is already %s", res.Status)}})
+		return
+	}
+	if errs := validateResultTransition(res.Status, change.Status); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid status change", errs)
+		return
+	}
+
+	transition := ResultTransition{Status: change.Status, At: time.Now(), Reason: change.Reason}
+	err = MongoTransitionResult(m, res.StartedAt, res.Status, transition)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result status was changed concurrently", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("ERROR changing result status: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not change result status", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(MongoGetResult(m, res.StartedAt))
+}
+
+// postResultEvaluation scores a result against a ground truth label set of its dataset and stores the scores in the
+// metrics of the result, evaluating again replaces the previous scores
+func postResultEvaluation(w http.ResponseWriter, r *http.Request) {
+	result := mux.Vars(r)["result"]
+	name := r.URL.Query().Get("ground_truth")
+	if name == "" {
+		name = defaultGroundTruth
+	}
+	fmt.Printf("REST call: postResultEvaluation - %s %s\n", result, name)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	startedAt, err := parseResultDate(result)
+	if err != nil {
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, result)
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	res := MongoGetResult(m, startedAt)
+	if res.Method == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result does not exist", Status: false})
+		return
+	}
+	groundTruth, err := MongoGetGroundTruth(m, res.DatasetName, name)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Ground truth does not exist for the dataset of the result", Status: false})
+		return
+	}
+
+	evaluation, err := evaluateResult(res, groundTruth, time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: err.Error(), Status: false})
+		return
+	}
+	err = MongoSetResultEvaluation(m, res.StartedAt, evaluation)
+	if err != nil {
+		fmt.Printf("ERROR storing evaluation: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not store evaluation", Status: false})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(evaluation)
+}
+
+// postResultComparison compares two or more results side by side
+func postResultComparison(w http.ResponseWriter, r *http.Request) {
+	var request ResultComparisonRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	fmt.Printf("REST call: postResultComparison - %v\n", request.Results)
+
+	var errs []ValidationError
+	if len(request.Results) < 2 {
+		errs = append(errs, ValidationError{Field: "results", Message: "at least two results must be given"})
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	var results []Result
+	seen := make(map[time.Time]bool)
+	for i, startedAt := range request.Results {
+		field := fmt.Sprintf("results[%d]", i)
+		startedAt = startedAt.UTC()
+		if seen[startedAt] {
+			errs = append(errs, ValidationError{Field: field, Message: "result is given more than once"})
+			continue
+		}
+		seen[startedAt] = true
+		result := MongoGetResult(m, startedAt)
+		if result.Method == "" {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("no result started at %s", startedAt.Format(time.RFC3339Nano))})
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid comparison", errs)
+		return
+	}
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(compareResults(results))
+}
+
+func deleteResult(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	result := params["result"]
+
+	fmt.Printf("REST call: deleteResult - %s\n", result)
+
+	// parse time
+	startedAt, err := parseResultDate(result)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, result)
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ok := MongoDeleteResult(m, startedAt)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if ok {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result successfully deleted", Status: true})
+		return
+	} else {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete result", Status: false})
+	}
+}
+
+func getCrawlerJobs(w http.ResponseWriter, r *http.Request) {
+
+	fmt.Printf("REST call: getCrawlerJobs\n")
+
+	// retrieve all dataset names
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	crawlerJobs := MongoGetCrawlerJobs(m)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(crawlerJobs)
+
+}
+
+func postCrawlerJobs(w http.ResponseWriter, r *http.Request) {
+	var crawlerJobs CrawlerJobs
+
+	s, err := ioutil.ReadAll(r.Body) 
+	if err != nil {
+		panic(err) 
+	}
+
+	err = json.Unmarshal(s, &crawlerJobs)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	job, err := crawlerJobFromLegacy(crawlerJobs)
+	if err != nil {
+		writeValidationErrors(w, "Invalid crawler job", []ValidationError{{Field: "request", Message: err.Error()}})
+		return
+	}
+	job, errs := prepareCrawlerJob(m, job)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid crawler job", errs)
+		return
+	}
+
+	_, err = MongoInsertCrawlerJob(m, job)
+	if err != nil {
+		fmt.Printf("ERROR %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		panic(err)
+	}
+
+	// send response
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+}
+
+func deleteCrawlerJob(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	crawlerJobDate := params["job"]
+
+	// error finding
+	for k, v := range mux.Vars(r) {
+		log.Printf("key=%v, value=%v", k, v)
+	}
+
+	fmt.Printf("REST call: deleteCrawlerJob: ")
+	fmt.Printf(crawlerJobDate)
+
+	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
+	var t Date
+	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ok := MongoDeleteCrawlerJob(m, t.Date)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if ok == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+		return
+	} else {
+		fmt.Printf("error deleting crawler job: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+	}
+}
+
+
+
+func updateCrawlerJob(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	crawlerJobDate := params["job"]
+
+	// error finding
+	for k, v := range mux.Vars(r) {
+		log.Printf("key=%v, value=%v", k, v)
+	}
+
+	fmt.Printf("REST call: updateCrawlerJob: ")
+	fmt.Printf(crawlerJobDate)
+
+	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
+	var t Date
+	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ok := MongoUpdateCrawlerJob(m, t.Date)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if ok == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+		return
+	} else {
+		fmt.Printf("error deleting crawler job: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+	}
+}
+
+func getAppReviewCrawlerJobs(w http.ResponseWriter, r *http.Request) {
+
+	fmt.Printf("REST call: getCrawlerJobs\n")
+
+	// retrieve all dataset names
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	crawlerJobs := MongoGetAppReviewCrawlerJobs(m)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(crawlerJobs)
+
+}
+
+func postAppReviewCrawlerJobs(w http.ResponseWriter, r *http.Request) {
+	var appReviewCrawlerJobs AppReviewCrawlerJobs
+
+	s, err := ioutil.ReadAll(r.Body) 
+	if err != nil {
+		panic(err) 
+	}
+	fmt.Printf("%+v\n", s)
+	err = json.Unmarshal(s, &appReviewCrawlerJobs)
+	fmt.Printf("%+v\n", appReviewCrawlerJobs)
+	if err != nil {	
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	job, err := appReviewCrawlerJobFromLegacy(appReviewCrawlerJobs)
+	if err != nil {
+		writeValidationErrors(w, "Invalid crawler job", []ValidationError{{Field: "request", Message: err.Error()}})
+		return
+	}
+	job, errs := prepareCrawlerJob(m, job)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid crawler job", errs)
+		return
+	}
+	
+	_, err = MongoInsertCrawlerJob(m, job)
+	if err != nil {
+		fmt.Printf("ERROR %s\n", err)
+		w.WriteHeader(http.StatusBadRequest)
+		panic(err)
+	}
+	
+	// send response
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+}
+
+
+func deleteAppReviewCrawlerJob(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	crawlerJobDate := params["job"]
+
+	// error finding
+	for k, v := range mux.Vars(r) {
+		log.Printf("key=%v, value=%v", k, v)
+	}
+
+	fmt.Printf("REST call: deleteCrawlerJob: ")
+	fmt.Printf(crawlerJobDate)
+
+	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
+	var t Date
+	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ok := MongoDeleteAppReviewCrawlerJob(m, t.Date)
+
+	// write the response
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if ok == nil {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+		return
+	} else {
+		fmt.Printf("error deleting crawler job: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+	}
+}
+
+
+
+func updateAppReviewCrawlerJob(w http.ResponseWriter, r *http.Request) {
+
+	params := mux.Vars(r)
+	crawlerJobDate := params["job"]
+
+	// error finding
+	for k, v := range mux.Vars(r) {
+		log.Printf("key=%v, value=%v", k, v)
+	}
+
+	fmt.Printf("REST call: updateCrawlerJob: ")
+	fmt.Printf(crawlerJobDate)
+
+	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
+	var t Date
+	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		return
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	ok := MongoUpdateAppReviewCrawlerJob(m, t.Date)
 
 	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if err == nil {
+	if ok == nil {
 		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation successfully deleted", Status: true})
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
 		return
 	} else {
-		fmt.Printf("error deleting annotation: %s", err)
+		fmt.Printf("error deleting crawler job: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete annotation", Status: false})
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
 	}
 }
 
-func deleteAgreement(w http.ResponseWriter, r *http.Request) {
-
+func getRecommendationTores(w http.ResponseWriter, r *http.Request) {
+    // get request param
 	params := mux.Vars(r)
-	agreementName := params["agreement"]
+	codename := params["codename"]
 
-	fmt.Printf("REST call: deleteAgreement - %s\n", agreementName)
+	fmt.Println("REST call: getRecommendationTores, params: ", codename)
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	err := MongoDeleteAgreement(m, agreementName)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	recommendation := MongoGetRecommendation(m, codename)
+	recommendationTores := []string{}
+	recommendationTores = append(recommendationTores, recommendation.Torecodes...)
+
+	// fall back to the recommendations derived from the existing annotations
+	if len(recommendationTores) == 0 {
+		for _, suggestion := range recommendTores(m, defaultScheme, codename, recommendationMatchAny).Suggestions {
+			recommendationTores = append(recommendationTores, suggestion.Tore)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(bson.M{"recommendationTores": recommendationTores})
+}
+
+// getToreRecommendation returns the tores ranked by how often they were assigned to a code name or lemma
+func getToreRecommendation(w http.ResponseWriter, r *http.Request) {
+	term := mux.Vars(r)["term"]
+	match := r.URL.Query().Get("match")
+	scheme := schemeOrDefault(r.URL.Query().Get("scheme"))
+
+	fmt.Printf("REST call: getToreRecommendation, term: %s, match: %s, scheme: %s\n", term, match, scheme)
 
-	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if err == nil {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Agreement successfully deleted", Status: true})
+	if recommendationKinds(match) == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Match must be name, lemma or any", Status: false})
 		return
-	} else {
-		fmt.Printf("error deleting agreement: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete agreement", Status: false})
 	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	recommendation := recommendTores(m, scheme, term, match)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(recommendation)
 }
 
-func deleteDataset(w http.ResponseWriter, r *http.Request) {
+// postRecommendationBatch returns the tore suggestions for many code names or lemmas and, if an annotation name is
+// given, for every uncoded token of that annotation in a single response
+func postRecommendationBatch(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: postRecommendationBatch\n")
+	var request RecommendationBatchRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
-	params := mux.Vars(r)
-	dataset := params["dataset"]
+	var errs []ValidationError
+	if recommendationKinds(request.Match) == nil {
+		errs = append(errs, ValidationError{Field: "match", Message: "match must be name, lemma or any"})
+	}
+	if len(request.Terms) > maxRecommendationBatchTerms {
+		errs = append(errs, ValidationError{Field: "terms", Message: fmt.Sprintf("at most %d terms can be requested at once", maxRecommendationBatchTerms)})
+	}
+	if len(request.Terms) == 0 && request.AnnotationName == "" {
+		errs = append(errs, ValidationError{Field: "terms", Message: "terms or annotation_name must be given"})
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid recommendation request", errs)
+		return
+	}
 
-	fmt.Printf("REST call: deleteDataset - %s\n", dataset)
+	m := requestDatabase(r)
+	defer m.Session.Close()
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	ok := MongoDeleteDataset(m, dataset)
+	var annotation Annotation
+	if request.AnnotationName != "" {
+		var exists bool
+		annotation, exists = MongoFindAnnotation(m, request.AnnotationName)
+		if !exists {
+			w.Header().Set(contentTypeKey, contentTypeValJSON)
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Annotation does not exist", Status: false})
+			return
+		}
+	}
+	scheme := request.Scheme
+	if scheme == "" {
+		scheme = annotation.Scheme
+	}
+	scheme = schemeOrDefault(scheme)
+
+	// collect every term that is looked up so the statistics are fetched in one query
+	lookup := append([]string{}, request.Terms...)
+	tokenIndices := uncodedTokens(annotation)
+	for _, index := range tokenIndices {
+		for _, term := range tokenLookupTerms(annotation.Tokens[index]) {
+			lookup = append(lookup, term)
+		}
+	}
+	statistics := MongoGetRecommendationStatisticsForTerms(m, scheme, lookup)
+
+	response := RecommendationBatchResponse{
+		Terms:  recommendTerms(statistics, request.Terms, request.Match),
+		Tokens: recommendTokens(statistics, annotation, tokenIndices, request.Match),
+	}
+
+	// stored recommendations of the default scheme take precedence, like for a single codename
+	if scheme == defaultScheme && len(request.Terms) > 0 {
+		torecodes := make(map[string][]string)
+		for _, recommendation := range MongoGetRecommendationsForCodenames(m, request.Terms) {
+			torecodes[recommendation.Codename] = recommendation.Torecodes
+		}
+		for i, term := range request.Terms {
+			response.Terms[i].RecommendationTores = torecodes[term]
+		}
+	}
 
-	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if ok {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Dataset successfully deleted", Status: true})
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// recommendTores ranks the tores of the first statistic kind of the match mode that knows the term
+func recommendTores(m *mgo.Database, scheme string, term string, match string) TermRecommendation {
+	recommendation := TermRecommendation{Term: normalizeTerm(term), Suggestions: []ToreSuggestion{}}
+	for _, kind := range recommendationKinds(match) {
+		suggestions := rankToreSuggestions(MongoGetRecommendationStatistics(m, scheme, kind, term))
+		if len(suggestions) > 0 {
+			recommendation.MatchedBy = kind
+			recommendation.Suggestions = suggestions
+			break
+		}
+	}
+	return recommendation
+}
+
+// postRebuildRecommendations recomputes the derived recommendations from all annotations
+func postRebuildRecommendations(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: postRebuildRecommendations\n")
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	count, err := MongoRebuildRecommendationStatistics(m)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR rebuilding recommendations: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not rebuild recommendations", Status: false})
 		return
-	} else {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete dataset", Status: false})
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Rebuilt %d recommendation statistics", count), Status: true})
 }
 
-func deleteResult(w http.ResponseWriter, r *http.Request) {
+// findRouteCrawlerJob returns the job addressed by the route, by its id or on the legacy routes by crawler and creation
+// date. If there is no such job, the error response is written and false is returned.
+func findRouteCrawlerJob(w http.ResponseWriter, m *mgo.Database, params map[string]string) (CrawlerJob, bool) {
+	var job CrawlerJob
+	var err error
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if id, ok := params["id"]; ok {
+		if !bson.IsObjectIdHex(id) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse crawler job id", Status: false})
+			return job, false
+		}
+		job, err = MongoGetCrawlerJob(m, bson.ObjectIdHex(id))
+	} else {
+		date, parseErr := parseCrawlerJobDate(params["job"])
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
+			return job, false
+		}
+		job, err = MongoFindCrawlerJob(m, legacyCrawlerSources[params["crawler"]], date)
+	}
 
-	params := mux.Vars(r)
-	result := params["result"]
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job does not exist", Status: false})
+		return job, false
+	} else if err != nil {
+		fmt.Printf("ERROR finding crawler job: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not find crawler job", Status: false})
+		return job, false
+	}
+	return job, true
+}
 
-	fmt.Printf("REST call: deleteResult - %s\n", result)
+// getAllCrawlerJobs returns the jobs of all crawler sources, or of the source given as query parameter
+func getAllCrawlerJobs(w http.ResponseWriter, r *http.Request) {
+	source := r.URL.Query().Get("source")
+	fmt.Printf("REST call: getAllCrawlerJobs, source: %s\n", source)
 
-	_t := "{\"date\": \"" + result + "\"}"
-	// parse time
-	var t Date
-	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
-	if err != nil {
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if _, ok := crawlerSources[source]; source != "" && !ok {
 		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
-		fmt.Printf("ERROR parsing date: %s date: %s\n", err, result)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Unknown crawler source", Status: false})
 		return
 	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	ok := MongoDeleteResult(m, t.Date)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	jobs := MongoGetAllCrawlerJobs(m, source)
 
-	// write the response
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(jobs)
+}
+
+// getCrawlerJob returns a single crawler job
+func getCrawlerJob(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: getCrawlerJob - %s\n", params["id"])
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if ok {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Result successfully deleted", Status: true})
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
 		return
-	} else {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete result", Status: false})
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(job)
 }
 
-func getCrawlerJobs(w http.ResponseWriter, _ *http.Request) {
+// postCrawlerJob stores a job of any crawler source, the request is checked against the request type of the source
+func postCrawlerJob(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: postCrawlerJob\n")
+	var job CrawlerJob
+	err := json.NewDecoder(r.Body).Decode(&job)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
-	fmt.Printf("REST call: getCrawlerJobs\n")
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	job, errs := prepareCrawlerJob(m, job)
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid crawler job", errs)
+		return
+	}
 
-	// retrieve all dataset names
-	m := mongoClient.Copy()
-	defer m.Close()
-	crawlerJobs := MongoGetCrawlerJobs(m)
+	job, err = MongoInsertCrawlerJob(m, job)
 
-	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR inserting crawler job: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not store crawler job", Status: false})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(crawlerJobs)
-
+	_ = json.NewEncoder(w).Encode(job)
 }
 
-func postCrawlerJobs(w http.ResponseWriter, r *http.Request) {
-	var crawlerJobs CrawlerJobs
+// deleteCrawlerJobById removes a crawler job and its run history
+func deleteCrawlerJobById(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: deleteCrawlerJobById - %s\n", params["id"])
 
-	s, err := ioutil.ReadAll(r.Body) 
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
+		return
+	}
+	err := MongoDeleteCrawlerJobById(m, job.Id)
 	if err != nil {
-		panic(err) 
+		fmt.Printf("ERROR deleting crawler job: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+}
 
-	err = json.Unmarshal(s, &crawlerJobs)
+// prepareCrawlerJob validates a new job of any source and normalizes its request and schedule. The dataset of the
+// job must not exist yet, unless the job appends to it.
+func prepareCrawlerJob(m *mgo.Database, job CrawlerJob) (CrawlerJob, []ValidationError) {
+	if _, ok := crawlerSources[job.Source]; !ok {
+		return job, []ValidationError{{Field: "source", Message: fmt.Sprintf("unknown crawler source %q", job.Source)}}
+	}
+	job, request, err := normalizeCrawlerJob(job)
 	if err != nil {
-		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
-		w.WriteHeader(http.StatusBadRequest)
-		panic(err) 
+		return job, []ValidationError{{Field: "request", Message: err.Error()}}
 	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	err = MongoInsertCrawlerJobs(m, crawlerJobs)
+	errs := validateCrawlerJob(job, request)
+	if !job.AppendToDataset && job.DatasetName != "" && MongoDatasetExists(m, job.DatasetName) {
+		errs = append(errs, ValidationError{
+			Field:   "dataset_name",
+			Message: fmt.Sprintf("dataset %q already exists, set append_to_dataset to add to it", job.DatasetName),
+		})
+	}
+	var scheduleErrs []ValidationError
+	job.Schedule, scheduleErrs = prepareCrawlerSchedule(job.Schedule, job.Occurrence)
+	return job, append(errs, scheduleErrs...)
+}
+
+// prepareCrawlerSchedule validates the schedule of a new job and sets its first run. Jobs without a schedule repeat
+// by their occurrence.
+func prepareCrawlerSchedule(schedule *CrawlerSchedule, occurrence int) (*CrawlerSchedule, []ValidationError) {
+	if schedule == nil {
+		schedule = legacyCrawlerSchedule(occurrence)
+		if schedule == nil {
+			return nil, nil
+		}
+	}
+	if errs := validateCrawlerSchedule(*schedule); len(errs) > 0 {
+		return nil, errs
+	}
+	if schedule.NextRun == nil {
+		schedule.NextRun = nextCrawlerRun(*schedule, time.Now())
+	}
+	schedule.LockedBy = ""
+	schedule.LockedUntil = nil
+	return schedule, nil
+}
+
+// parseCrawlerJobDate parses the creation date identifying a crawler job
+func parseCrawlerJobDate(job string) (time.Time, error) {
+	var t Date
+	err := json.NewDecoder(strings.NewReader("{\"date\": \"" + job + "\"}")).Decode(&t)
+	return t.Date, err
+}
+
+// postDueCrawlerJobs leases the jobs that are due to the requesting worker. The worker has to report the outcome
+// before the lease ends, afterwards the jobs are handed to other workers again.
+func postDueCrawlerJobs(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: postDueCrawlerJobs\n")
+	var request DueCrawlerJobsRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		fmt.Printf("ERROR %s\n", err)
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		panic(err)
+		return
 	}
 
-	// send response
-	w.WriteHeader(http.StatusOK)
+	var errs []ValidationError
+	if request.Worker == "" {
+		errs = append(errs, ValidationError{Field: "worker", Message: "worker must not be empty"})
+	}
+	lease := defaultCrawlerLease
+	if request.LeaseSeconds != 0 {
+		lease = time.Duration(request.LeaseSeconds) * time.Second
+	}
+	if lease <= 0 || lease > maxCrawlerLease {
+		errs = append(errs, ValidationError{Field: "lease_seconds", Message: fmt.Sprintf("lease must be between 1 and %d seconds", int(maxCrawlerLease.Seconds()))})
+	}
+	if request.Limit < 0 {
+		errs = append(errs, ValidationError{Field: "limit", Message: "limit must not be negative"})
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid due jobs request", errs)
+		return
+	}
+	limit := request.Limit
+	if limit == 0 {
+		limit = 1
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	jobs, err := MongoLeaseDueCrawlerJobs(m, request.Worker, lease, limit)
+
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err != nil {
+		fmt.Printf("ERROR leasing crawler jobs: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not lease crawler jobs", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(DueCrawlerJobs{Jobs: jobs})
 }
 
-func deleteCrawlerJob(w http.ResponseWriter, r *http.Request) {
-
+// putCrawlerJobSchedule sets the interval or cron expression of a crawler job
+func putCrawlerJobSchedule(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	crawlerJobDate := params["job"]
+	fmt.Printf("REST call: putCrawlerJobSchedule - %v\n", params)
 
-	// error finding
-	for k, v := range mux.Vars(r) {
-		log.Printf("key=%v, value=%v", k, v)
+	var schedule CrawlerSchedule
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if errs := validateCrawlerSchedule(schedule); len(errs) > 0 {
+		writeValidationErrors(w, "Invalid crawler job schedule", errs)
+		return
+	}
+	if schedule.NextRun == nil {
+		schedule.NextRun = nextCrawlerRun(schedule, time.Now())
 	}
 
-	fmt.Printf("REST call: deleteCrawlerJob: ")
-	fmt.Printf(crawlerJobDate)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
+		return
+	}
+	err = MongoSetCrawlerJobSchedule(m, job.Id, schedule)
+	if err != nil {
+		fmt.Printf("ERROR updating crawler job schedule: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update crawler job schedule", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job schedule updated", Status: true})
+}
 
-	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
-	var t Date
-	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+// postCrawlerJobOutcome records how the run of a leased job ended and releases the lease
+func postCrawlerJobOutcome(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: postCrawlerJobOutcome - %v\n", params)
+
+	var outcome CrawlerRunOutcome
+	err := json.NewDecoder(r.Body).Decode(&outcome)
 	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
-		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		return
+	}
+	var errs []ValidationError
+	if outcome.Worker == "" {
+		errs = append(errs, ValidationError{Field: "worker", Message: "worker must not be empty"})
+	}
+	if outcome.Outcome != crawlerRunSucceeded && outcome.Outcome != crawlerRunFailed {
+		errs = append(errs, ValidationError{Field: "outcome", Message: "outcome must be succeeded or failed"})
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid crawler job outcome", errs)
 		return
 	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	ok := MongoDeleteCrawlerJob(m, t.Date)
-
-	// write the response
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if ok == nil {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
 		return
-	} else {
-		fmt.Printf("error deleting crawler job: %s", err)
+	}
+	err = MongoFinishCrawlerJobRun(m, job.Id, outcome)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job is not leased to the worker", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("ERROR finishing crawler job run: %s\n", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not record crawler job outcome", Status: false})
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job outcome recorded", Status: true})
 }
 
-
-
-func updateCrawlerJob(w http.ResponseWriter, r *http.Request) {
-
+// postCrawlerJobRun starts the run history entry of a job, workers report its progress afterwards
+func postCrawlerJobRun(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	crawlerJobDate := params["job"]
-
-	// error finding
-	for k, v := range mux.Vars(r) {
-		log.Printf("key=%v, value=%v", k, v)
-	}
-
-	fmt.Printf("REST call: updateCrawlerJob: ")
-	fmt.Printf(crawlerJobDate)
+	fmt.Printf("REST call: postCrawlerJobRun - %v\n", params)
 
-	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
-	var t Date
-	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+	var run CrawlerJobRun
+	err := json.NewDecoder(r.Body).Decode(&run)
 	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
-		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		return
+	}
+	if run.Status == "" {
+		run.Status = crawlerRunQueued
+	}
+	if run.Status != crawlerRunQueued && run.Status != crawlerRunRunning {
+		writeValidationErrors(w, "Invalid crawler job run", []ValidationError{{Field: "status", Message: "a new run must be queued or running"}})
 		return
 	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	ok := MongoUpdateCrawlerJob(m, t.Date)
-
-	// write the response
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if ok == nil {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
 		return
-	} else {
-		fmt.Printf("error deleting crawler job: %s", err)
+	}
+
+	now := time.Now()
+	run = CrawlerJobRun{
+		Id:          bson.NewObjectId(),
+		JobId:       job.Id,
+		Source:      job.Source,
+		Status:      run.Status,
+		Worker:      run.Worker,
+		QueuedAt:    now,
+		DatasetName: run.DatasetName,
+	}
+	if run.Status == crawlerRunRunning {
+		run.StartedAt = &now
+	}
+	err = MongoInsertCrawlerJobRun(m, run)
+	if err != nil {
+		fmt.Printf("ERROR inserting crawler job run: %s\n", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not store crawler job run", Status: false})
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(run)
 }
 
-func getAppReviewCrawlerJobs(w http.ResponseWriter, _ *http.Request) {
-
-	fmt.Printf("REST call: getCrawlerJobs\n")
-
-	// retrieve all dataset names
-	m := mongoClient.Copy()
-	defer m.Close()
-	crawlerJobs := MongoGetAppReviewCrawlerJobs(m)
+// getCrawlerJobRuns returns the run history of a job
+func getCrawlerJobRuns(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: getCrawlerJobRuns - %v\n", params)
 
-	// write the response
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(crawlerJobs)
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
+		return
+	}
+	runs := MongoGetCrawlerJobRuns(m, job.Id)
 
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(runs)
 }
 
-func postAppReviewCrawlerJobs(w http.ResponseWriter, r *http.Request) {
-	var appReviewCrawlerJobs AppReviewCrawlerJobs
+// getCrawlerJobRun returns a single run of a job
+func getCrawlerJobRun(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	fmt.Printf("REST call: getCrawlerJobRun - %v\n", params)
 
-	s, err := ioutil.ReadAll(r.Body) 
-	if err != nil {
-		panic(err) 
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
+		return
 	}
-	fmt.Printf("%+v\n", s)
-	err = json.Unmarshal(s, &appReviewCrawlerJobs)
-	fmt.Printf("%+v\n", appReviewCrawlerJobs)
-	if err != nil {	
-		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
+	if !bson.IsObjectIdHex(params["run"]) {
 		w.WriteHeader(http.StatusBadRequest)
-		panic(err) 
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse run id", Status: false})
+		return
 	}
-	m := mongoClient.Copy()
-	defer m.Close()
-	
-	err = MongoInsertAppReviewCrawlerJobs(m, appReviewCrawlerJobs)
+	run, err := MongoGetCrawlerJobRun(m, job.Id, bson.ObjectIdHex(params["run"]))
 	if err != nil {
-		fmt.Printf("ERROR %s\n", err)
-		w.WriteHeader(http.StatusBadRequest)
-		panic(err)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job run does not exist", Status: false})
+		return
 	}
-	
-	// send response
 	w.WriteHeader(http.StatusOK)
-	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	_ = json.NewEncoder(w).Encode(run)
 }
 
-
-func deleteAppReviewCrawlerJob(w http.ResponseWriter, r *http.Request) {
-
+// patchCrawlerJobRun records the progress a worker reports for a run
+func patchCrawlerJobRun(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
-	crawlerJobDate := params["job"]
-
-	// error finding
-	for k, v := range mux.Vars(r) {
-		log.Printf("key=%v, value=%v", k, v)
-	}
-
-	fmt.Printf("REST call: deleteCrawlerJob: ")
-	fmt.Printf(crawlerJobDate)
+	fmt.Printf("REST call: patchCrawlerJobRun - %v\n", params)
 
-	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
-	var t Date
-	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
+	var progress CrawlerRunProgress
+	err := json.NewDecoder(r.Body).Decode(&progress)
 	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
-		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
 		return
 	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	ok := MongoDeleteAppReviewCrawlerJob(m, t.Date)
-
-	// write the response
+	m := requestDatabase(r)
+	defer m.Session.Close()
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if ok == nil {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
+	job, ok := findRouteCrawlerJob(w, m, params)
+	if !ok {
 		return
-	} else {
-		fmt.Printf("error deleting crawler job: %s", err)
+	}
+	if !bson.IsObjectIdHex(params["run"]) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse run id", Status: false})
+		return
+	}
+	run, err := MongoGetCrawlerJobRun(m, job.Id, bson.ObjectIdHex(params["run"]))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job run does not exist", Status: false})
+		return
+	}
+	updated, errs := applyCrawlerRunProgress(run, progress, time.Now())
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid crawler job run progress", errs)
+		return
+	}
+
+	err = MongoUpdateCrawlerJobRun(m, updated, run.Status)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job run was changed concurrently", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("ERROR updating crawler job run: %s\n", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update crawler job run", Status: false})
+		return
 	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(updated)
 }
 
+func getAllCodesFromAnnotations(w http.ResponseWriter, r *http.Request) {
+    fmt.Printf("REST call: getAllAnnotationCodes\n")
 
+    // retrieve all dataset names
+    m := requestDatabase(r)
+    defer m.Session.Close()
+    annotations := MongoGetAllAnnotationsCodes(m)
 
-func updateAppReviewCrawlerJob(w http.ResponseWriter, r *http.Request) {
-
-	params := mux.Vars(r)
-	crawlerJobDate := params["job"]
+    // write the response
+    w.Header().Set(contentTypeKey, contentTypeValJSON)
+    w.WriteHeader(http.StatusOK)
+    _ = json.NewEncoder(w).Encode(annotations)
+}
 
-	// error finding
-	for k, v := range mux.Vars(r) {
-		log.Printf("key=%v, value=%v", k, v)
+// getCodeConcordance returns all codes matching a name, tore and/or relationship together with their surrounding tokens
+func getCodeConcordance(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query := concordanceQuery{
+		Name:         values.Get("name"),
+		Tore:         values.Get("tore"),
+		Relationship: values.Get("relationship"),
+		Window:       defaultConcordanceWindow,
 	}
 
-	fmt.Printf("REST call: updateCrawlerJob: ")
-	fmt.Printf(crawlerJobDate)
+	fmt.Printf("REST call: getCodeConcordance, name: %s, tore: %s, relationship: %s\n", query.Name, query.Tore, query.Relationship)
 
-	_t := "{\"date\": \"" + crawlerJobDate + "\"}"
-	var t Date
-	err := json.NewDecoder(strings.NewReader(_t)).Decode(&t)
-	if err != nil {
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if query.Name == "" && query.Tore == "" && query.Relationship == "" {
 		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not parse date", Status: false})
-		fmt.Printf("ERROR parsing date: %s date: %s\n", err, crawlerJobDate)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "At least one of name, tore or relationship is required", Status: false})
 		return
 	}
+	if window := values.Get("window"); window != "" {
+		size, err := strconv.Atoi(window)
+		if err != nil || size < 0 || size > maxConcordanceWindow {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: fmt.Sprintf("Window must be a number between 0 and %d", maxConcordanceWindow), Status: false})
+			return
+		}
+		query.Window = size
+	}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	ok := MongoUpdateAppReviewCrawlerJob(m, t.Date)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	annotations := MongoGetAnnotationsForConcordance(m, query.Name, query.Tore, query.Relationship)
 
-	// write the response
-	w.Header().Set(contentTypeKey, contentTypeValJSON)
-	if ok == nil {
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Crawler job successfully deleted", Status: true})
-		return
-	} else {
-		fmt.Printf("error deleting crawler job: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete crawler job", Status: false})
+	hits := []ConcordanceHit{}
+	for _, annotation := range annotations {
+		hits = append(hits, findConcordanceHits(annotation, query)...)
 	}
-}
 
-func getRecommendationTores(w http.ResponseWriter, r *http.Request) {
-    // get request param
-	params := mux.Vars(r)
-	codename := params["codename"]
+	// write the response
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(hits)
+}
 
-	fmt.Println("REST call: getRecommendationTores, params: ", codename)
+// getAllAnnotationStatistics returns code statistics across all annotations
+func getAllAnnotationStatistics(w http.ResponseWriter, r *http.Request) {
+	fmt.Printf("REST call: getAllAnnotationStatistics\n")
+	writeAnnotationStatistics(w, r, bson.M{})
+}
 
-	m := mongoClient.Copy()
-	defer m.Close()
-	recommendation := MongoGetRecommendation(m, codename)
-	recommendationTores := []string{}
-	recommendationTores = append(recommendationTores, recommendation.Torecodes...)
+// getAnnotationStatistics returns code statistics of the annotation with a given name
+func getAnnotationStatistics(w http.ResponseWriter, r *http.Request) {
+	annotationName := mux.Vars(r)["annotation"]
+	fmt.Println("REST call: getAnnotationStatistics, params: " + annotationName)
+	writeAnnotationStatistics(w, r, bson.M{fieldAnnotationName: annotationName})
+}
 
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(bson.M{"recommendationTores": recommendationTores})
+// getDatasetAnnotationStatistics returns code statistics of all annotations of a given dataset
+func getDatasetAnnotationStatistics(w http.ResponseWriter, r *http.Request) {
+	dataset := mux.Vars(r)["dataset"]
+	fmt.Println("REST call: getDatasetAnnotationStatistics, params: " + dataset)
+	writeAnnotationStatistics(w, r, bson.M{"dataset": dataset})
 }
 
-func getAllCodesFromAnnotations(w http.ResponseWriter, r *http.Request) {
-    fmt.Printf("REST call: getAllAnnotationCodes\n")
+func writeAnnotationStatistics(w http.ResponseWriter, r *http.Request, query bson.M) {
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
 
-    // retrieve all dataset names
-    m := mongoClient.Copy()
-    defer m.Close()
-    annotations := MongoGetAllAnnotationsCodes(m)
+	limit := defaultStatisticsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Limit must be a non-negative number (0 for no limit)", Status: false})
+			return
+		}
+		limit = parsed
+	}
 
-    // write the response
-    w.Header().Set(contentTypeKey, contentTypeValJSON)
-    w.WriteHeader(http.StatusOK)
-    _ = json.NewEncoder(w).Encode(annotations)
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	statistics, err := MongoGetAnnotationStatistics(m, query, limit)
+	if err != nil {
+		fmt.Printf("ERROR computing annotation statistics: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not compute statistics", Status: false})
+		return
+	}
+
+	// write the response
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(statistics)
 }
 
-// store all recommendations
+// store all recommendations, replacing the existing ones
 func postRecommendations(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("REST call: postRecommendations\n")
 	var recommendations []Recommendation
@@ -912,28 +3161,132 @@ func postRecommendations(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		panic(err)
+		return
 	}
 
-	// insert data into the db
-	m := mongoClient.Copy()
-	defer m.Close()
+	// codenames are unique, reject duplicates before touching the stored recommendations
+	var errs []ValidationError
+	seen := make(map[string]bool)
+	for i, recommendation := range recommendations {
+		if recommendation.Codename == "" {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("[%d].codename", i), Message: "codename must not be empty"})
+		} else if seen[recommendation.Codename] {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("[%d].codename", i), Message: fmt.Sprintf("duplicate codename %q", recommendation.Codename)})
+		}
+		seen[recommendation.Codename] = true
+	}
+	if len(errs) > 0 {
+		writeValidationErrors(w, "Invalid recommendations", errs)
+		return
+	}
+
+	// replace data in the db
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	response, err := MongoReplaceRecommendations(m, recommendations)
 
-	err = MongoDeleteRecommendationAll(m)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	if err != nil {
 		fmt.Printf("ERROR %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not store recommendations, the previous recommendations are kept", Status: false})
+		return
+	}
+
+	// send response
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// putRecommendation creates or replaces the recommendation of a single codename
+func putRecommendation(w http.ResponseWriter, r *http.Request) {
+	codename := mux.Vars(r)["codename"]
+	fmt.Printf("REST call: putRecommendation - %s\n", codename)
+
+	var recommendation Recommendation
+	err := json.NewDecoder(r.Body).Decode(&recommendation)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		panic(err)
+		return
 	}
+	recommendation.Codename = codename
+	if recommendation.Torecodes == nil {
+		recommendation.Torecodes = []string{}
+	}
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	inserted, err := MongoUpsertRecommendation(m, recommendation)
 
-	err = MongoInsertManyRecommendations(m, recommendations)
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
 	if err != nil {
 		fmt.Printf("ERROR %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not store recommendation", Status: false})
+		return
+	}
+	response := RecommendationUpdateResponse{Updated: 1}
+	if inserted {
+		response = RecommendationUpdateResponse{Inserted: 1}
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// patchRecommendation adds or removes torecodes of the recommendation of a codename
+func patchRecommendation(w http.ResponseWriter, r *http.Request) {
+	codename := mux.Vars(r)["codename"]
+	fmt.Printf("REST call: patchRecommendation - %s\n", codename)
+
+	var update RecommendationUpdate
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		fmt.Printf("ERROR decoding json: %s for request body: %v\n", err, r.Body)
 		w.WriteHeader(http.StatusBadRequest)
-		panic(err)
+		return
 	}
 
-	// send response
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	recommendation, err := MongoPatchRecommendation(m, codename, update)
+
+	w.Header().Set(contentTypeKey, contentTypeValJSON)
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Recommendation does not exist", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("ERROR %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not update recommendation", Status: false})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(recommendation)
+}
+
+// deleteRecommendation removes the recommendation of a codename
+func deleteRecommendation(w http.ResponseWriter, r *http.Request) {
+	codename := mux.Vars(r)["codename"]
+	fmt.Printf("REST call: deleteRecommendation - %s\n", codename)
+
+	m := requestDatabase(r)
+	defer m.Session.Close()
+	err := MongoDeleteRecommendation(m, codename)
+
+	// write the response
 	w.Header().Set(contentTypeKey, contentTypeValJSON)
-}
\ No newline at end of file
+	if err == mgo.ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Recommendation does not exist", Status: false})
+		return
+	} else if err != nil {
+		fmt.Printf("error deleting recommendation: %s\n", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(ResponseMessage{Message: "Could not delete recommendation", Status: false})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(RecommendationUpdateResponse{Removed: 1})
+}
@@ -2,15 +2,21 @@ package main
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 	"gopkg.in/mgo.v2/dbtest"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -18,6 +24,7 @@ import (
 )
 
 var router *mux.Router
+var testDB *mgo.Database
 var mockDBServer dbtest.DBServer
 var documents []Document
 var ti = time.Now()
@@ -44,6 +51,7 @@ func setup() {
 }
 
 func setupRouter() {
+	authn = authenticator{disabled: true}
 	router = makeRouter()
 }
 
@@ -52,7 +60,9 @@ func setupDB() {
 	mockDBServer.SetPath(tempDir)
 
 	mongoClient = mockDBServer.Session()
-	MongoCreateCollectionIndexes(mongoClient)
+	testDB = mongoClient.DB(database)
+	MongoMigrateProjects(testDB, nil)
+	MongoCreateCollectionIndexes(testDB)
 }
 
 func addDatasets() {
@@ -148,6 +158,24 @@ func (e endpoint) mustExecuteRequest(payload interface{}) *httptest.ResponseReco
 	return rr
 }
 
+func (e endpoint) mustExecuteRequestWithHeaders(payload interface{}, headers ...string) *httptest.ResponseRecorder {
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(payload); err != nil {
+		panic(errors.Wrap(err, `Could not encode payload`))
+	}
+	req, err := http.NewRequest(e.method, e.url, body)
+	if err != nil {
+		panic(errors.Wrap(err, `Could not execute request`))
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
 func isSuccess(code int) bool {
 	return code >= 200 && code < 300
 }
@@ -196,16 +224,16 @@ func TestPostDataset(t *testing.T) {
 	// Test with normal dataset
 	assertSuccess(t, ep.mustExecuteRequest(validDatasetPayload))
 
-	d := MongoGetAllDatasets(mongoClient)
+	d := MongoGetAllDatasets(testDB)
 	assert.Len(t, d, 1)
 
 	// Test with exising dataset name
 	assertSuccess(t, ep.mustExecuteRequest(validDatasetPayload))
 
-	d = MongoGetAllDatasets(mongoClient)
+	d = MongoGetAllDatasets(testDB)
 	assert.Len(t, d, 1)
 
-	MongoDeleteDataset(mongoClient, "test_dataset_5")
+	MongoDeleteDataset(testDB, "test_dataset_5")
 
 	// Test invalid payload
 	assertFailure(t, ep.mustExecuteRequest(invalidObjectPayload))
@@ -336,7 +364,7 @@ func TestGetAllDetectionResults(t *testing.T) {
 	assertJsonDecodes(t, response, &content)
 	assert.Len(t, content, 1)
 
-	MongoDeleteResult(mongoClient, ti)
+	MongoDeleteResult(testDB, ti)
 	// Test with no results
 	response = ep.mustExecuteRequest(nil)
 	assertJsonDecodes(t, response, &content)
@@ -347,13 +375,13 @@ func TestDeleteDataset(t *testing.T) {
 	// Test normal
 	ep := endpoint{"DELETE", "/hitec/repository/concepts/dataset/name/test_dataset_1"}
 	ep.mustExecuteRequest(nil)
-	datasets := MongoGetAllDatasets(mongoClient)
+	datasets := MongoGetAllDatasets(testDB)
 	assert.Len(t, datasets, 2)
 
 	// Test non-existent dataset
 	ep = endpoint{"DELETE", "/hitec/repository/concepts/dataset/name/test_dataset_4"}
 	ep.mustExecuteRequest(nil)
-	datasets = MongoGetAllDatasets(mongoClient)
+	datasets = MongoGetAllDatasets(testDB)
 	assert.Len(t, datasets, 2)
 
 }
@@ -367,14 +395,14 @@ func TestDeleteResult(t *testing.T) {
 		DatasetName: "test_dataset_2",
 		Name:        "test_result",
 	}
-	_ = MongoInsertResult(mongoClient, res)
+	_ = MongoInsertResult(testDB, res)
 
 	// Test with non-existent result
 	tm := time.Now().Format("2006-01-02T15:04:05Z07:00")
 	ep := endpoint{"DELETE", "/hitec/repository/concepts/detection/result/" + tm}
 	assertSuccess(t, ep.mustExecuteRequest(nil))
 
-	results := MongoGetAllResults(mongoClient)
+	results := MongoGetAllResults(testDB)
 	assert.Len(t, results, 1)
 
 	// Test with wrong date format
@@ -388,14 +416,1509 @@ func TestDeleteResult(t *testing.T) {
 	fmt.Println(tm)
 	ep = endpoint{"DELETE", "/hitec/repository/concepts/detection/result/" + tm}
 	assertSuccess(t, ep.mustExecuteRequest(nil))
-	results = MongoGetAllResults(mongoClient)
+	results = MongoGetAllResults(testDB)
 	assert.Len(t, results, 0)
 }
 
+func intPtr(i int) *int {
+	return &i
+}
+
+func testAnnotation(name string, dataset string) Annotation {
+	words := []string{"The", "app", "crashes", "when", "I", "upload", "a", "photo", "Login", "fails"}
+	var tokens []Token
+	for i, word := range words {
+		tokens = append(tokens, Token{Index: intPtr(i), Name: word, Lemma: strings.ToLower(word), Pos: "NN"})
+	}
+	annotation := Annotation{
+		UploadedAt: time.Now(),
+		Name:       name,
+		Dataset:    dataset,
+		Docs: []DocWrapper{
+			{Name: "0", BeginIndex: intPtr(0), EndIndex: intPtr(8)},
+			{Name: "1", BeginIndex: intPtr(8), EndIndex: intPtr(10)},
+		},
+		Tokens: tokens,
+		Codes: []Code{
+			{Index: intPtr(0), Name: "crash", Tore: "Task", Tokens: []*int{intPtr(2)}, RelationshipMemberships: []*int{intPtr(0)}},
+			{Index: intPtr(1), Name: "photo", Tore: "Domain Data", Tokens: []*int{intPtr(7)}},
+			{Index: intPtr(2), Name: "login", Tore: "Task", Tokens: []*int{intPtr(8), intPtr(9)}},
+		},
+		TORERelationships: []TORERelationship{
+			{Index: intPtr(0), TOREEntity: intPtr(0), TargetTokens: []*int{intPtr(7)}, RelationshipName: "uses"},
+		},
+	}
+	for _, code := range annotation.Codes {
+		for _, token := range code.Tokens {
+			annotation.Tokens[*token].NumNameCodes++
+			annotation.Tokens[*token].NumToreCodes++
+		}
+	}
+	return annotation
+}
+
+func TestGetCodeConcordance(t *testing.T) {
+	_ = MongoInsertAnnotation(testDB, testAnnotation("test_annotation_concordance", "test_dataset_2"))
+
+	// Test by tore with a small window
+	ep := endpoint{"GET", "/hitec/repository/concepts/annotationcodes/concordance?tore=task&window=2"}
+	response := ep.mustExecuteRequest(nil)
+	assertSuccess(t, response)
+	var hits []ConcordanceHit
+	assertJsonDecodes(t, response, &hits)
+	assert.Len(t, hits, 2)
+	assert.Equal(t, "The app", hits[0].LeftContext)
+	assert.Equal(t, "crashes", hits[0].Match)
+	assert.Equal(t, "when I", hits[0].RightContext)
+	assert.Equal(t, "0", hits[0].Document)
+	assert.Equal(t, "Login fails", hits[1].Match)
+	assert.Equal(t, "", hits[1].LeftContext)
+
+	// Test by relationship
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotationcodes/concordance?relationship=uses"}
+	response = ep.mustExecuteRequest(nil)
+	assertJsonDecodes(t, response, &hits)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, "crash", hits[0].CodeName)
+
+	// Test without filter and with invalid window
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotationcodes/concordance"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotationcodes/concordance?name=crash&window=x"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	// Test a code spanning a document boundary
+	annotation := testAnnotation("test_annotation_concordance", "test_dataset_2")
+	annotation.Codes[1].Tokens = []*int{intPtr(7), intPtr(8)}
+	hits = findConcordanceHits(annotation, concordanceQuery{Name: "photo", Window: 2})
+	assert.Len(t, hits, 1)
+	assert.Equal(t, "photo Login", hits[0].Match)
+	assert.Equal(t, "upload a", hits[0].LeftContext)
+	assert.Equal(t, "", hits[0].RightContext)
+	assert.Equal(t, "0", hits[0].Document)
+
+	// Test the context of a document with a negative begin starts at the first token
+	annotation.Docs[0].BeginIndex = intPtr(-3)
+	hits = findConcordanceHits(annotation, concordanceQuery{Name: "photo", Window: 10})
+	assert.Len(t, hits, 1)
+	assert.Equal(t, joinTokenNames(annotation.Tokens[:7]), hits[0].LeftContext)
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_concordance")
+}
+
+func TestGetAnnotationStatistics(t *testing.T) {
+	_ = MongoInsertAnnotation(testDB, testAnnotation("test_annotation_statistics", "test_dataset_3"))
+
+	ep := endpoint{"GET", "/hitec/repository/concepts/annotation/statistics/name/test_annotation_statistics"}
+	response := ep.mustExecuteRequest(nil)
+	assertSuccess(t, response)
+	var statistics AnnotationStatistics
+	assertJsonDecodes(t, response, &statistics)
+	assert.Equal(t, 1, statistics.NumAnnotations)
+	assert.Equal(t, 3, statistics.NumCodes)
+	assert.Equal(t, 10, statistics.NumTokens)
+	assert.Equal(t, 4, statistics.NumCodedTokens)
+	assert.Equal(t, StatisticsCount{Name: "Task", Count: 2}, statistics.ToreCounts[0])
+	assert.Equal(t, StatisticsCount{Name: "uses", Count: 1}, statistics.RelationshipCounts[0])
+	assert.Len(t, statistics.DocumentCoverage, 2)
+	assert.Equal(t, 1.0, statistics.DocumentCoverage[1].Coverage)
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/statistics/dataset/test_dataset_3?limit=1"}
+	response = ep.mustExecuteRequest(nil)
+	assertJsonDecodes(t, response, &statistics)
+	assert.Len(t, statistics.CodeNameCounts, 1)
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/statistics/all?limit=x"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_statistics")
+}
+
+func TestToreCategories(t *testing.T) {
+	// Test legacy list of tore types
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/annotation/tores/"}
+	assertSuccess(t, ep.mustExecuteRequest(map[string][]string{"tores": {"Task", "Domain Data", "Goal"}}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/tores/"}
+	assertSuccess(t, ep.mustExecuteRequest(map[string][]string{"tores": {"Task", "Domain Data"}}))
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/tores/categories"}
+	response := ep.mustExecuteRequest(nil)
+	var categories []ToreCategory
+	assertJsonDecodes(t, response, &categories)
+	assert.Len(t, categories, 3)
+	goal, err := MongoGetToreCategory(testDB, defaultScheme, "Goal")
+	assert.NoError(t, err)
+	assert.Equal(t, toreCategoryDeprecated, goal.State)
+	assert.Equal(t, toreLevelTask, goal.Level)
+	tores, err := MongoGetAllTORE(testDB, defaultScheme)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Domain Data", "Task"}, tores)
+
+	// Test creating a category
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/tores/category/"}
+	assertSuccess(t, ep.mustExecuteRequest(ToreCategory{Name: "Activity", Color: "#1f77b4", Description: "What the user does"}))
+	assertFailure(t, ep.mustExecuteRequest(ToreCategory{Name: "Invalid", Color: "blue", Level: "Unknown"}))
+
+	// Test annotation validation against the taxonomy
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	annotation := testAnnotation("test_annotation_tores", "test_dataset_2")
+	annotation.Codes[1].Tore = "Goal"
+	assertFailure(t, ep.mustExecuteRequest(annotation))
+	annotation.Codes[1].Tore = "Activity"
+	assertSuccess(t, ep.mustExecuteRequest(annotation))
+
+	// Test renaming rewrites existing codes
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/tores/category/Activity"}
+	assertSuccess(t, ep.mustExecuteRequest(ToreCategory{Name: "User Activity", Level: toreLevelDomain}))
+	stored, _ := MongoFindAnnotation(testDB, "test_annotation_tores")
+	assert.Equal(t, "User Activity", stored.Codes[1].Tore)
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/tores/category/Activity"}
+	assertFailure(t, ep.mustExecuteRequest(ToreCategory{Name: "Activity"}))
+
+	// Test deleting a category still in use
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/tores/category/User%20Activity"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/tores/category/Goal"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_tores")
+	_, _ = mongoClient.DB(database).C(collectionToreCategories).RemoveAll(nil)
+}
+
+func TestRelationshipTypes(t *testing.T) {
+	// Test legacy lists of names and owners
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/annotation/relationships/"}
+	assertFailure(t, ep.mustExecuteRequest(map[string][]string{"relationship_names": {"uses", "part of"}, "owners": {"Task"}}))
+	assertSuccess(t, ep.mustExecuteRequest(map[string][]string{"relationship_names": {"uses", "part of"}, "owners": {"Task", "Domain Data"}}))
+	names, owners, err := MongoGetAllRelationshipNames(testDB, defaultScheme)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"part of", "uses"}, names)
+	assert.Equal(t, []string{"Domain Data", "Task"}, owners)
+
+	// Test adding constraints
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/relationships/type/"}
+	assertSuccess(t, ep.mustExecuteRequest(RelationshipType{Name: "uses", Owner: "Task", SourceTores: []string{"Task"}, TargetTores: []string{"Domain Data"}}))
+	assertFailure(t, ep.mustExecuteRequest(RelationshipType{Owner: "Task"}))
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/relationships/types"}
+	response := ep.mustExecuteRequest(nil)
+	var relationshipTypes []RelationshipType
+	assertJsonDecodes(t, response, &relationshipTypes)
+	assert.Len(t, relationshipTypes, 2)
+
+	// Test annotation validation against the constraints
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	annotation := testAnnotation("test_annotation_relationships", "test_dataset_2")
+	annotation.TORERelationships[0].TargetTokens = []*int{intPtr(2)}
+	response = ep.mustExecuteRequest(annotation)
+	assertFailure(t, response)
+	var validation ValidationResponse
+	assertJsonDecodes(t, response, &validation)
+	assert.Len(t, validation.Errors, 1)
+	assert.Equal(t, "tore_relationships[0].target_tokens", validation.Errors[0].Field)
+
+	annotation.TORERelationships[0].TargetTokens = []*int{intPtr(7)}
+	assertSuccess(t, ep.mustExecuteRequest(annotation))
+
+	// Test removing a type still in use from the legacy list
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/relationships/"}
+	assertFailure(t, ep.mustExecuteRequest(map[string][]string{"relationship_names": {"part of"}, "owners": {"Domain Data"}}))
+	names, _, err = MongoGetAllRelationshipNames(testDB, defaultScheme)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"part of", "uses"}, names)
+
+	// Test deleting a type still in use
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/relationships/type/uses"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/relationships/type/part%20of"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_relationships")
+	_, _ = mongoClient.DB(database).C(collectionRelationshipTypes).RemoveAll(nil)
+}
+
+func TestAnnotationSchemes(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/annotation/scheme/"}
+	assertSuccess(t, ep.mustExecuteRequest(AnnotationScheme{Name: "study_b", Description: "Second study"}))
+	assertFailure(t, ep.mustExecuteRequest(AnnotationScheme{}))
+
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/tores/?scheme=study_b"}
+	assertSuccess(t, ep.mustExecuteRequest(map[string][]string{"tores": {"Feature"}}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/tores/"}
+	assertSuccess(t, ep.mustExecuteRequest(map[string][]string{"tores": {"Task"}}))
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/tores?scheme=study_b"}
+	response := ep.mustExecuteRequest(nil)
+	var tores map[string][]string
+	assertJsonDecodes(t, response, &tores)
+	assert.Equal(t, []string{"Feature"}, tores["tores"])
+
+	// Test unknown schemes are rejected and empty schemes have no tores
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/tores?scheme=study_x"}
+	assert.Equal(t, http.StatusNotFound, ep.mustExecuteRequest(nil).Code)
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/tores/?scheme=study_x"}
+	assert.Equal(t, http.StatusBadRequest, ep.mustExecuteRequest(map[string][]string{"tores": {"Feature"}}).Code)
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/relationships/?scheme=study_x"}
+	assert.Equal(t, http.StatusBadRequest, ep.mustExecuteRequest(map[string][]string{"relationship_names": {"uses"}, "owners": {"Feature"}}).Code)
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/scheme/"}
+	assertSuccess(t, ep.mustExecuteRequest(AnnotationScheme{Name: "study_c", Description: "Empty study"}))
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/tores?scheme=study_c"}
+	response = ep.mustExecuteRequest(nil)
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &tores)
+	assert.Equal(t, []string{}, tores["tores"])
+
+	// Test updating a category of the scheme given in the body
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/tores/category/Feature"}
+	assertSuccess(t, ep.mustExecuteRequest(ToreCategory{Scheme: "study_b", Name: "Feature", Description: "A product feature"}))
+	feature, err := MongoGetToreCategory(testDB, "study_b", "Feature")
+	assert.NoError(t, err)
+	assert.Equal(t, "A product feature", feature.Description)
+
+	// Test annotations are validated against the scheme they are bound to
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	annotation := testAnnotation("test_annotation_scheme", "test_dataset_2")
+	annotation.Scheme = "unknown"
+	assertFailure(t, ep.mustExecuteRequest(annotation))
+	annotation.Scheme = "study_b"
+	assertFailure(t, ep.mustExecuteRequest(annotation))
+	for i := range annotation.Codes {
+		annotation.Codes[i].Tore = "Feature"
+	}
+	assertSuccess(t, ep.mustExecuteRequest(annotation))
+	annotation.Scheme = defaultScheme
+	assertFailure(t, ep.mustExecuteRequest(annotation))
+	annotation.Scheme = ""
+	assertSuccess(t, ep.mustExecuteRequest(annotation))
+	stored, _ := MongoFindAnnotation(testDB, "test_annotation_scheme")
+	assert.Equal(t, "study_b", stored.Scheme)
+
+	// Test deleting a scheme still in use
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/scheme/study_b"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_scheme")
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	assert.Empty(t, MongoGetToreCategories(testDB, "study_b"))
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/scheme/" + defaultScheme}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/scheme/study_c"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+
+	_, _ = mongoClient.DB(database).C(collectionToreCategories).RemoveAll(nil)
+}
+
+func TestToreRecommendation(t *testing.T) {
+	first := testAnnotation("test_annotation_recommendation_1", "test_dataset_2")
+	second := testAnnotation("test_annotation_recommendation_2", "test_dataset_2")
+	second.Codes[0].Tore = "Activity"
+	second.Codes[0].Name = "Crash "
+	_ = MongoInsertAnnotation(testDB, first)
+	_ = MongoInsertAnnotation(testDB, second)
+
+	// Test case-insensitive name matching
+	ep := endpoint{"GET", "/hitec/repository/concepts/annotation/recommendation/CRASH"}
+	response := ep.mustExecuteRequest(nil)
+	assertSuccess(t, response)
+	var recommendation TermRecommendation
+	assertJsonDecodes(t, response, &recommendation)
+	assert.Equal(t, recommendationMatchName, recommendation.MatchedBy)
+	assert.Len(t, recommendation.Suggestions, 2)
+	assert.Equal(t, 0.5, recommendation.Suggestions[0].Confidence)
+
+	// Test lemma matching
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/recommendation/login%20fails?match=lemma"}
+	response = ep.mustExecuteRequest(nil)
+	assertJsonDecodes(t, response, &recommendation)
+	assert.Equal(t, []ToreSuggestion{{Tore: "Task", Count: 2, Confidence: 1}}, recommendation.Suggestions)
+
+	// Test incremental update when an annotation changes
+	second.Codes[0].Tore = "Task"
+	_ = MongoInsertAnnotation(testDB, second)
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/recommendation/crash"}
+	response = ep.mustExecuteRequest(nil)
+	assertJsonDecodes(t, response, &recommendation)
+	assert.Equal(t, []ToreSuggestion{{Tore: "Task", Count: 2, Confidence: 1}}, recommendation.Suggestions)
+
+	// Test fallback of the precomputed recommendations
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/recommendationTores/photo"}
+	response = ep.mustExecuteRequest(nil)
+	var tores map[string][]string
+	assertJsonDecodes(t, response, &tores)
+	assert.Equal(t, []string{"Domain Data"}, tores["recommendationTores"])
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/recommendation/crash?match=other"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	// Test the statistics are built once at startup
+	_, _ = testDB.C(collectionMigration).RemoveAll(nil)
+	_, _ = testDB.C(collectionRecommendationStats).RemoveAll(nil)
+	MongoMigrateRecommendationStatistics(testDB)
+	assert.Len(t, MongoGetRecommendationStatistics(testDB, defaultScheme, recommendationMatchName, "crash"), 1)
+	_, _ = testDB.C(collectionRecommendationStats).RemoveAll(nil)
+	MongoMigrateRecommendationStatistics(testDB)
+	assert.Empty(t, MongoGetRecommendationStatistics(testDB, defaultScheme, recommendationMatchName, "crash"))
+
+	// Test rebuild and removal with the annotations
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/recommendations/rebuild"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_recommendation_1")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_recommendation_2")
+	assert.Empty(t, MongoGetRecommendationStatistics(testDB, defaultScheme, recommendationMatchName, "crash"))
+}
+
+func TestRecommendationBatch(t *testing.T) {
+	coded := testAnnotation("test_annotation_batch_1", "test_dataset_2")
+	uncoded := testAnnotation("test_annotation_batch_2", "test_dataset_2")
+	uncoded.Codes = nil
+	uncoded.TORERelationships = nil
+	_ = MongoInsertAnnotation(testDB, coded)
+	_ = MongoInsertAnnotation(testDB, uncoded)
+	_, _ = MongoUpsertRecommendation(testDB, Recommendation{Codename: "login", Torecodes: []string{"Activity"}})
+
+	ep := endpoint{"POST", "/hitec/repository/concepts/annotation/recommendation/batch"}
+	response := ep.mustExecuteRequest(RecommendationBatchRequest{Terms: []string{"Crash", "login", "unknown"}, AnnotationName: uncoded.Name})
+	assertSuccess(t, response)
+	var batch RecommendationBatchResponse
+	assertJsonDecodes(t, response, &batch)
+	assert.Len(t, batch.Terms, 3)
+	assert.Equal(t, []ToreSuggestion{{Tore: "Task", Count: 1, Confidence: 1}}, batch.Terms[0].Suggestions)
+	assert.Equal(t, []string{"Activity"}, batch.Terms[1].RecommendationTores)
+	assert.Empty(t, batch.Terms[2].Suggestions)
+
+	// Test every uncoded token gets suggestions, by name or by lemma
+	assert.Len(t, batch.Tokens, 10)
+	assert.Equal(t, recommendationMatchLemma, batch.Tokens[2].MatchedBy)
+	assert.Equal(t, "Task", batch.Tokens[2].Suggestions[0].Tore)
+	assert.Equal(t, recommendationMatchName, batch.Tokens[7].MatchedBy)
+	assert.Equal(t, "Domain Data", batch.Tokens[7].Suggestions[0].Tore)
+
+	// Test invalid requests
+	assertFailure(t, ep.mustExecuteRequest(RecommendationBatchRequest{}))
+	assertFailure(t, ep.mustExecuteRequest(RecommendationBatchRequest{Terms: []string{"crash"}, Match: "other"}))
+	assertFailure(t, ep.mustExecuteRequest(RecommendationBatchRequest{AnnotationName: "unknown"}))
+
+	_ = MongoDeleteAnnotation(testDB, coded.Name)
+	_ = MongoDeleteAnnotation(testDB, uncoded.Name)
+	_ = MongoDeleteRecommendation(testDB, "login")
+}
+
+func TestPostRecommendations(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/recommendations/"}
+	response := ep.mustExecuteRequest([]Recommendation{
+		{Codename: "login", Torecodes: []string{"Task"}},
+		{Codename: "photo", Torecodes: []string{"Domain Data"}},
+	})
+	assertSuccess(t, response)
+	var update RecommendationUpdateResponse
+	assertJsonDecodes(t, response, &update)
+	assert.Equal(t, RecommendationUpdateResponse{Inserted: 2}, update)
+
+	// Test duplicates are rejected and the stored recommendations are kept
+	assertFailure(t, ep.mustExecuteRequest([]Recommendation{{Codename: "login"}, {Codename: "login"}}))
+	assert.Equal(t, []string{"Task"}, MongoGetRecommendation(testDB, "login").Torecodes)
+
+	response = ep.mustExecuteRequest([]Recommendation{
+		{Codename: "login", Torecodes: []string{"Task", "Activity"}},
+		{Codename: "upload", Torecodes: []string{"Activity"}},
+	})
+	assertJsonDecodes(t, response, &update)
+	assert.Equal(t, RecommendationUpdateResponse{Inserted: 1, Updated: 1, Removed: 1}, update)
+
+	// Test single recommendation endpoints
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/recommendations/photo"}
+	response = ep.mustExecuteRequest(Recommendation{Torecodes: []string{"Domain Data"}})
+	assertJsonDecodes(t, response, &update)
+	assert.Equal(t, RecommendationUpdateResponse{Inserted: 1}, update)
+
+	ep = endpoint{"PATCH", "/hitec/repository/concepts/store/recommendations/login"}
+	assertSuccess(t, ep.mustExecuteRequest(RecommendationUpdate{AddTorecodes: []string{"Goal"}, RemoveTorecodes: []string{"Activity"}}))
+	assert.Equal(t, []string{"Task", "Goal"}, MongoGetRecommendation(testDB, "login").Torecodes)
+	ep = endpoint{"PATCH", "/hitec/repository/concepts/store/recommendations/unknown"}
+	assertFailure(t, ep.mustExecuteRequest(RecommendationUpdate{AddTorecodes: []string{"Goal"}}))
+
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/store/recommendations/upload"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	_ = MongoDeleteRecommendation(testDB, "login")
+	_ = MongoDeleteRecommendation(testDB, "photo")
+}
+
+func TestDatasetIngest(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/dataset/test_ingest/ingest"}
+	response := ep.mustExecuteRequest(DatasetIngest{Documents: []Document{{Id: "a", Text: "First post"}, {Id: "b", Text: "Second post"}}})
+	assertSuccess(t, response)
+	var record DatasetIngestRecord
+	assertJsonDecodes(t, response, &record)
+	assert.True(t, record.Created)
+	assert.Equal(t, 2, record.Added)
+	assert.Equal(t, 1, record.DatasetVersion)
+	assertFailure(t, ep.mustExecuteRequest(DatasetIngest{Documents: []Document{{Id: "e", Text: "Post"}}, CrawlerJobId: bson.NewObjectId()}))
+
+	// Test duplicates by id and by normalised text are skipped
+	job, _ := MongoInsertCrawlerJob(testDB, CrawlerJob{Source: crawlerSourceReddit, DatasetName: "test_ingest"})
+	run := CrawlerJobRun{Id: bson.NewObjectId(), JobId: job.Id, Source: job.Source, Status: crawlerRunRunning}
+	_ = MongoInsertCrawlerJobRun(testDB, run)
+	response = ep.mustExecuteRequest(DatasetIngest{
+		Documents:    []Document{{Id: "a", Text: "Changed"}, {Id: "c", Text: "  first   POST "}, {Id: "d", Text: "Third post"}},
+		CrawlerJobId: job.Id,
+		CrawlerRunId: run.Id,
+	})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &record)
+	assert.Equal(t, 1, record.Added)
+	assert.Equal(t, 2, record.Duplicates)
+	assert.Equal(t, 3, record.Size)
+	assert.Equal(t, 2, record.DatasetVersion)
+
+	dataset := MongoGetDataset(testDB, "test_ingest")
+	assert.Equal(t, 3, dataset.Size)
+	assert.Equal(t, Document{Number: 2, Id: "d", Text: "Third post"}, dataset.Documents[2])
+	count, _ := testDB.C(collectionDataset).Find(bson.M{fieldDatasetName: "test_ingest", fieldDatasetVersion: 2}).Count()
+	assert.Equal(t, 1, count)
+	run, _ = MongoGetCrawlerJobRun(testDB, job.Id, run.Id)
+	assert.Equal(t, "test_ingest", run.DatasetName)
+	assert.NotNil(t, run.IngestedAt)
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/dataset/name/test_ingest/ingests"}
+	response = ep.mustExecuteRequest(nil)
+	var ingests []DatasetIngestRecord
+	assertJsonDecodes(t, response, &ingests)
+	assert.Len(t, ingests, 2)
+
+	// Test invalid ingests
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/dataset/test_ingest/ingest"}
+	assertFailure(t, ep.mustExecuteRequest(DatasetIngest{}))
+	assertFailure(t, ep.mustExecuteRequest(DatasetIngest{Documents: []Document{{Text: "text"}}, CrawlerJobId: job.Id, CrawlerRunId: bson.NewObjectId()}))
+
+	_ = MongoDeleteCrawlerJobById(testDB, job.Id)
+	MongoDeleteDataset(testDB, "test_ingest")
+}
+
+func crawlerJobIds(jobs []CrawlerJob) []bson.ObjectId {
+	var ids []bson.ObjectId
+	for _, job := range jobs {
+		ids = append(ids, job.Id)
+	}
+	return ids
+}
+
+func TestCrawlerJobs(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/crawler/jobs"}
+	response := ep.mustExecuteRequest(CrawlerJob{
+		Source:  crawlerSourceReddit,
+		Request: map[string]interface{}{"subreddits": []string{"test_unified"}, "dataset_name": "test_unified", "comment_depth": 2, "post_selection": "new"},
+	})
+	assertSuccess(t, response)
+	var job CrawlerJob
+	assertJsonDecodes(t, response, &job)
+	assert.Equal(t, "test_unified", job.Name)
+	assert.Equal(t, "test_unified", job.DatasetName)
+	assertFailure(t, ep.mustExecuteRequest(CrawlerJob{Source: "unknown"}))
+
+	// Test the unified and the legacy listing
+	ep = endpoint{"GET", "/hitec/repository/concepts/crawler/jobs?source=reddit"}
+	response = ep.mustExecuteRequest(nil)
+	var jobs []CrawlerJob
+	assertJsonDecodes(t, response, &jobs)
+	assert.Contains(t, crawlerJobIds(jobs), job.Id)
+	ep = endpoint{"GET", "/hitec/repository/concepts/crawler/jobs?source=app_review"}
+	response = ep.mustExecuteRequest(nil)
+	assertJsonDecodes(t, response, &jobs)
+	assert.NotContains(t, crawlerJobIds(jobs), job.Id)
+	legacy := false
+	for _, j := range MongoGetCrawlerJobs(testDB) {
+		legacy = legacy || (j.SubredditName == "test_unified" && j.Request.CommentDepth == 2)
+	}
+	assert.True(t, legacy)
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/crawler/jobs/" + job.Id.Hex()}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/store/crawler/jobs/" + job.Id.Hex()}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"GET", "/hitec/repository/concepts/crawler/jobs/" + job.Id.Hex()}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+}
+
+func TestMigrateCrawlerJobs(t *testing.T) {
+	legacy := bson.M{
+		"_id":             bson.NewObjectId(),
+		"subreddit_names": "test_migrated",
+		"date":            time.Now(),
+		"dataset_name":    "test_migrated",
+		"request":         CrawlerRequest{Subreddits: []string{"test_migrated"}, DatasetName: "test_migrated", PostSelection: "new"},
+	}
+	assert.NoError(t, testDB.C(collectionCrawlerJobs).Insert(legacy))
+	MongoMigrateCrawlerJobs(testDB)
+	job, err := MongoGetCrawlerJob(testDB, legacy["_id"].(bson.ObjectId))
+	assert.NoError(t, err)
+	assert.Equal(t, "test_migrated", job.Name)
+
+	// Test repeating a migration interrupted before the legacy documents were moved
+	_ = testDB.C(collectionCrawlerJobs + "_migrated").DropCollection()
+	assert.NoError(t, testDB.C(collectionCrawlerJobs).Insert(legacy))
+	MongoMigrateCrawlerJobs(testDB)
+	count, _ := testDB.C(collectionCrawlerJob).Find(bson.M{"name": "test_migrated"}).Count()
+	assert.Equal(t, 1, count)
+
+	// Test legacy documents with a malformed request stay unmigrated
+	malformed := bson.M{"_id": bson.NewObjectId(), "subreddit_names": "test_malformed", "date": time.Now(), "request": bson.M{"subreddits": 5}}
+	assert.NoError(t, testDB.C(collectionCrawlerJobs).Insert(malformed))
+	MongoMigrateCrawlerJobs(testDB)
+	_, err = MongoGetCrawlerJob(testDB, malformed["_id"].(bson.ObjectId))
+	assert.Equal(t, mgo.ErrNotFound, err)
+	count, _ = testDB.C(collectionCrawlerJobs).FindId(malformed["_id"]).Count()
+	assert.Equal(t, 1, count)
+	_ = testDB.C(collectionCrawlerJobs).RemoveId(malformed["_id"])
+
+	_ = MongoDeleteCrawlerJobById(testDB, job.Id)
+	_ = testDB.C(collectionCrawlerJobs + "_migrated").DropCollection()
+}
+
+func TestCrawlerJobValidation(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/crawler/jobs"}
+	response := ep.mustExecuteRequest(CrawlerJob{
+		Source: crawlerSourceReddit,
+		Request: map[string]interface{}{
+			"subreddits":     []string{},
+			"dataset_name":   "test_validation",
+			"date_from":      "2021-04-01",
+			"date_to":        "2021-03-01",
+			"comment_depth":  -1,
+			"post_selection": "best",
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	var validation ValidationResponse
+	assertJsonDecodes(t, response, &validation)
+	var fields []string
+	for _, err := range validation.Errors {
+		fields = append(fields, err.Field)
+	}
+	assert.ElementsMatch(t, []string{"request.subreddits", "request.date_to", "request.comment_depth", "request.post_selection"}, fields)
+
+	// Test the app url has to point to the app store
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/app_review_crawler/jobs"}
+	request := AppReviewCrawlerRequest{AppName: "App", AppUrl: "https://example.com/app", DatasetName: "test_validation", PostSelection: "mostrecent"}
+	assertFailure(t, ep.mustExecuteRequest(AppReviewCrawlerJobs{AppName: "App", DatasetName: "test_validation", Request: request}))
+	request.AppUrl = "https://apps.apple.com/us/app/some-app/id123456789"
+	assertSuccess(t, ep.mustExecuteRequest(AppReviewCrawlerJobs{AppName: "App", DatasetName: "test_validation", Request: request}))
+
+	// Test an existing dataset is only accepted when appending
+	dataset := Dataset{Name: "test_validation", UploadedAt: time.Now(), Documents: []Document{{Number: 0, Text: "text", Id: "0"}}}
+	_ = MongoInsertDataset(testDB, dataset)
+	assertFailure(t, ep.mustExecuteRequest(AppReviewCrawlerJobs{AppName: "App", DatasetName: "test_validation", Request: request}))
+	assertSuccess(t, ep.mustExecuteRequest(AppReviewCrawlerJobs{AppName: "App", DatasetName: "test_validation", Request: request, AppendToDataset: true}))
+
+	for _, job := range MongoGetAppReviewCrawlerJobs(testDB) {
+		if job.DatasetName == "test_validation" {
+			_ = MongoDeleteAppReviewCrawlerJob(testDB, job.Date)
+		}
+	}
+	MongoDeleteDataset(testDB, dataset.Name)
+}
+
+func TestCrawlerJobSchedule(t *testing.T) {
+	// Test cron expressions
+	from := time.Date(2021, 3, 5, 10, 7, 30, 0, time.UTC)
+	cron, err := parseCron("*/15 9-17 * * 1-5")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 5, 10, 15, 0, 0, time.UTC), cron.next(from))
+	cron, _ = parseCron("0 2 * * 0")
+	assert.Equal(t, time.Date(2021, 3, 7, 2, 0, 0, 0, time.UTC), cron.next(from))
+	cron, err = parseCron("0 2 * * 7")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 7, 2, 0, 0, 0, time.UTC), cron.next(from))
+	_, err = parseCron("61 * * * *")
+	assert.Error(t, err)
+	_, err = parseCron("0 2 * * 8")
+	assert.EqualError(t, err, "day of week field must be within 0-7")
+
+	// Test a job repeating by its occurrence gets a schedule
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/reddit_crawler/jobs"}
+	request := CrawlerRequest{Subreddits: []string{"test_schedule"}, DatasetName: "test_schedule", PostSelection: "new"}
+	assertSuccess(t, ep.mustExecuteRequest(CrawlerJobs{SubredditName: "test_schedule", DatasetName: "test_schedule", Occurrence: 2, Request: request}))
+	assertFailure(t, ep.mustExecuteRequest(CrawlerJobs{SubredditName: "test_schedule", DatasetName: "test_schedule", Request: request, Schedule: &CrawlerSchedule{Cron: "every day"}}))
+	var job CrawlerJobs
+	for _, j := range MongoGetCrawlerJobs(testDB) {
+		if j.SubredditName == "test_schedule" {
+			job = j
+		}
+	}
+	assert.Equal(t, 2*24*60, job.Schedule.IntervalMinutes)
+	jobPath := "/hitec/repository/concepts/store/reddit_crawler/jobs/" + job.Date.UTC().Format(time.RFC3339Nano)
+
+	// Test the job is leased once it is due
+	past := time.Now().Add(-time.Minute)
+	ep = endpoint{"PUT", jobPath + "/schedule"}
+	assertSuccess(t, ep.mustExecuteRequest(CrawlerSchedule{IntervalMinutes: 60, NextRun: &past}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/crawler_jobs/due"}
+	response := ep.mustExecuteRequest(DueCrawlerJobsRequest{Worker: "worker_a", Limit: 10})
+	assertSuccess(t, response)
+	var due DueCrawlerJobs
+	assertJsonDecodes(t, response, &due)
+	assert.Len(t, due.Jobs, 1)
+	assert.Equal(t, "worker_a", due.Jobs[0].Schedule.LockedBy)
+
+	// Test a leased job is not handed to another worker
+	response = ep.mustExecuteRequest(DueCrawlerJobsRequest{Worker: "worker_b", Limit: 10})
+	assertJsonDecodes(t, response, &due)
+	assert.Empty(t, due.Jobs)
+	assertFailure(t, ep.mustExecuteRequest(DueCrawlerJobsRequest{}))
+
+	// Test only the leasing worker can report the outcome, which schedules the next run
+	ep = endpoint{"POST", jobPath + "/outcome"}
+	assertFailure(t, ep.mustExecuteRequest(CrawlerRunOutcome{Worker: "worker_b", Outcome: crawlerRunSucceeded}))
+	assertSuccess(t, ep.mustExecuteRequest(CrawlerRunOutcome{Worker: "worker_a", Outcome: crawlerRunFailed, Error: "rate limited"}))
+	for _, j := range MongoGetCrawlerJobs(testDB) {
+		if j.SubredditName == "test_schedule" {
+			job = j
+		}
+	}
+	assert.Equal(t, crawlerRunFailed, job.Schedule.LastOutcome)
+	assert.Equal(t, "", job.Schedule.LockedBy)
+	assert.True(t, job.Schedule.NextRun.After(time.Now().Add(59*time.Minute)))
+
+	// Test unparsable job dates are answered with JSON
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/reddit_crawler/jobs/not_a_date/schedule"}
+	response = ep.mustExecuteRequest(CrawlerSchedule{IntervalMinutes: 60})
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, contentTypeValJSON, response.Header().Get(contentTypeKey))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/reddit_crawler/jobs/not_a_date/outcome"}
+	response = ep.mustExecuteRequest(CrawlerRunOutcome{Worker: "worker_a", Outcome: crawlerRunSucceeded})
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, contentTypeValJSON, response.Header().Get(contentTypeKey))
+
+	_ = MongoDeleteCrawlerJob(testDB, job.Date)
+}
+
+func TestCrawlerJobRuns(t *testing.T) {
+	_ = MongoInsertAppReviewCrawlerJobs(testDB, AppReviewCrawlerJobs{AppName: "test_runs", DatasetName: "test_runs", Occurrence: 1})
+	var job AppReviewCrawlerJobs
+	for _, j := range MongoGetAppReviewCrawlerJobs(testDB) {
+		if j.AppName == "test_runs" {
+			job = j
+		}
+	}
+	jobDate := job.Date.UTC().Format(time.RFC3339Nano)
+
+	// Test a run is queued for an existing job only
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/app_review_crawler/jobs/" + jobDate + "/runs"}
+	response := ep.mustExecuteRequest(CrawlerJobRun{Worker: "worker_a"})
+	assertSuccess(t, response)
+	var run CrawlerJobRun
+	assertJsonDecodes(t, response, &run)
+	assert.Equal(t, crawlerRunQueued, run.Status)
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/reddit_crawler/jobs/" + jobDate + "/runs"}
+	assertFailure(t, ep.mustExecuteRequest(CrawlerJobRun{}))
+
+	// Test progress reports
+	ep = endpoint{"PATCH", "/hitec/repository/concepts/store/app_review_crawler/jobs/" + jobDate + "/runs/" + run.Id.Hex()}
+	assertSuccess(t, ep.mustExecuteRequest(CrawlerRunProgress{Status: crawlerRunRunning, NumberPosts: intPtr(10)}))
+	response = ep.mustExecuteRequest(CrawlerRunProgress{Status: crawlerRunSucceeded, NumberPosts: intPtr(25), DatasetName: "test_runs"})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &run)
+	assert.Equal(t, 25, run.NumberPosts)
+	assert.NotNil(t, run.StartedAt)
+	assert.NotNil(t, run.FinishedAt)
+	assertFailure(t, ep.mustExecuteRequest(CrawlerRunProgress{Status: crawlerRunRunning}))
+
+	// Test the run history
+	ep = endpoint{"GET", "/hitec/repository/concepts/app_review_crawler/jobs/" + jobDate + "/runs"}
+	response = ep.mustExecuteRequest(nil)
+	var runs []CrawlerJobRun
+	assertJsonDecodes(t, response, &runs)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, crawlerRunSucceeded, runs[0].Status)
+	for _, j := range MongoGetAppReviewCrawlerJobs(testDB) {
+		if j.AppName == "test_runs" {
+			assert.Equal(t, 25, j.NumberPosts)
+		}
+	}
+
+	stored, _ := MongoFindCrawlerJob(testDB, crawlerSourceAppReview, job.Date)
+	_ = MongoDeleteAppReviewCrawlerJob(testDB, job.Date)
+	assert.Empty(t, MongoGetCrawlerJobRuns(testDB, stored.Id))
+}
+
+func TestGroundTruth(t *testing.T) {
+	base := "/hitec/repository/concepts/dataset/name/test_dataset_3/groundtruth"
+	store := "/hitec/repository/concepts/store/dataset/test_dataset_3/groundtruth/"
+
+	// Test creating label sets
+	ep := endpoint{"POST", store}
+	assertSuccess(t, ep.mustExecuteRequest(GroundTruth{Name: "relevance", Elements: []TruthElement{{Id: "0", Value: "relevant"}}}))
+	assertSuccess(t, ep.mustExecuteRequest(GroundTruth{Name: defaultGroundTruth, Elements: []TruthElement{{Id: "1", Value: "a"}}}))
+	response := ep.mustExecuteRequest(GroundTruth{Name: "invalid", Elements: []TruthElement{{Id: "0", Value: "a"}, {Id: "0", Value: "b"}, {Id: "99", Value: "c"}}})
+	assertFailure(t, response)
+	var validation ValidationResponse
+	assertJsonDecodes(t, response, &validation)
+	assert.Len(t, validation.Errors, 2)
+	assertFailure(t, ep.mustExecuteRequest(GroundTruth{}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/dataset/test_dataset_99/groundtruth/"}
+	assertFailure(t, ep.mustExecuteRequest(GroundTruth{Name: "relevance"}))
+
+	ep = endpoint{"GET", base}
+	var groundTruths []GroundTruth
+	assertJsonDecodes(t, ep.mustExecuteRequest(nil), &groundTruths)
+	assert.Len(t, groundTruths, 2)
+
+	// Test updating and removing elements
+	ep = endpoint{"PUT", base + "/relevance/element/1"}
+	assertSuccess(t, ep.mustExecuteRequest(TruthElement{Value: "irrelevant"}))
+	ep = endpoint{"PUT", base + "/relevance/element/0"}
+	assertSuccess(t, ep.mustExecuteRequest(TruthElement{Value: "irrelevant"}))
+	ep = endpoint{"PUT", base + "/relevance/element/99"}
+	assertFailure(t, ep.mustExecuteRequest(TruthElement{Value: "irrelevant"}))
+	ep = endpoint{"PUT", base + "/missing/element/0"}
+	assertFailure(t, ep.mustExecuteRequest(TruthElement{Value: "irrelevant"}))
+	ep = endpoint{"DELETE", base + "/relevance/element/1"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	ep = endpoint{"GET", base + "/relevance"}
+	var groundTruth GroundTruth
+	assertJsonDecodes(t, ep.mustExecuteRequest(nil), &groundTruth)
+	assert.Equal(t, []TruthElement{{Id: "0", Value: "irrelevant"}}, groundTruth.Elements)
+
+	// Test the coverage report
+	ep = endpoint{"GET", base + "/relevance/coverage"}
+	var coverage GroundTruthCoverage
+	assertJsonDecodes(t, ep.mustExecuteRequest(nil), &coverage)
+	assert.Equal(t, 1, coverage.Covered)
+	assert.Equal(t, map[string]int{"irrelevant": 1}, coverage.Values)
+	assert.Contains(t, coverage.Uncovered, "1")
+
+	// Test the default label set is the ground truth of the dataset
+	dataset := MongoGetDataset(testDB, "test_dataset_3")
+	assert.Equal(t, []TruthElement{{Id: "1", Value: "a"}}, dataset.GroundTruth)
+	ep = endpoint{"DELETE", base + "/" + defaultGroundTruth}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	assert.Empty(t, MongoGetDataset(testDB, "test_dataset_3").GroundTruth)
+
+	// Test re-posting the dataset keeps the label sets in sync
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/dataset/"}
+	dataset.GroundTruth = []TruthElement{{Id: "1", Value: "b"}}
+	assertSuccess(t, ep.mustExecuteRequest(dataset))
+	groundTruth, _ = MongoGetGroundTruth(testDB, "test_dataset_3", defaultGroundTruth)
+	assert.Equal(t, dataset.GroundTruth, groundTruth.Elements)
+	dataset.GroundTruth = nil
+	assertSuccess(t, ep.mustExecuteRequest(dataset))
+	_, err := MongoGetGroundTruth(testDB, "test_dataset_3", defaultGroundTruth)
+	assert.Equal(t, mgo.ErrNotFound, err)
+	dataset.Documents = documents[1:]
+	response = ep.mustExecuteRequest(dataset)
+	assertFailure(t, response)
+	assertJsonDecodes(t, response, &validation)
+	assert.Equal(t, "groundtruth[relevance].elements[0].id", validation.Errors[0].Field)
+	assert.Len(t, MongoGetDataset(testDB, "test_dataset_3").Documents, len(documents))
+
+	ep = endpoint{"DELETE", base + "/relevance"}
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+}
+
+func TestResultEvaluation(t *testing.T) {
+	startedAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	res := Result{
+		Method:      "lda",
+		Status:      "finished",
+		StartedAt:   startedAt,
+		DatasetName: "test_dataset_3",
+		Name:        "test_result_evaluation",
+		Codes:       []Code{{Name: "Login"}, {Name: "crash"}},
+		DocTopic:    map[string]interface{}{"0": []interface{}{0.9, 0.1}, "1": []interface{}{0.2, 0.8}, "2": []interface{}{0.3, 0.7}},
+		Metrics:     map[string]interface{}{"perplexity": 12.5},
+	}
+	_ = MongoInsertResult(testDB, res)
+	_ = MongoSaveGroundTruth(testDB, GroundTruth{Dataset: "test_dataset_3", Name: "evaluation", Elements: []TruthElement{
+		{Id: "0", Value: "login; battery"},
+		{Id: "1", Value: "crash"},
+		{Id: "2", Value: "crash"},
+	}})
+	tm := startedAt.Format("2006-01-02T15:04:05.000Z07:00")
+
+	// Test evaluating against a label set
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + tm + "/evaluation?ground_truth=evaluation"}
+	response := ep.mustExecuteRequest(nil)
+	assertSuccess(t, response)
+	var evaluation ResultEvaluation
+	assertJsonDecodes(t, response, &evaluation)
+	assert.Equal(t, "evaluation", evaluation.GroundTruth)
+	assert.Equal(t, 2, evaluation.Codes.TruePositives)
+	assert.Equal(t, 1.0, evaluation.Codes.Precision)
+	assert.InDelta(t, 2.0/3, evaluation.Codes.Recall, 1e-9)
+	assert.InDelta(t, 0.8, evaluation.Codes.F1, 1e-9)
+	assert.Equal(t, 1.0, evaluation.Topics.Purity)
+	assert.Equal(t, 1.0, evaluation.Topics.NMI)
+
+	stored := MongoGetResult(testDB, startedAt)
+	metrics := flattenMetrics(stored.Metrics)
+	assert.Equal(t, 12.5, metrics["perplexity"])
+	assert.InDelta(t, 0.8, metrics["evaluation.codes.f1"], 1e-9)
+	assert.Equal(t, 1.0, metrics["evaluation.topics.nmi"])
+
+	// Test evaluating again replaces the scores and removes those not computed again
+	_ = MongoSaveGroundTruth(testDB, GroundTruth{Dataset: "test_dataset_3", Name: "evaluation", Elements: []TruthElement{{Id: "1", Value: "crash"}}})
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	metrics = flattenMetrics(MongoGetResult(testDB, startedAt).Metrics)
+	assert.Equal(t, 1.0, metrics["evaluation.codes.recall"])
+	assert.Equal(t, 12.5, metrics["perplexity"])
+	_, err := testDB.C(collectionResult).UpdateAll(bson.M{fieldResultStartedAt: startedAt}, bson.M{"$set": bson.M{"doc_topic": nil}})
+	assert.NoError(t, err)
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	metrics = flattenMetrics(MongoGetResult(testDB, startedAt).Metrics)
+	assert.NotContains(t, metrics, "evaluation.topics.nmi")
+
+	// Test evaluating a result without metrics
+	_, err = testDB.C(collectionResult).UpdateAll(bson.M{fieldResultStartedAt: startedAt}, bson.M{"$set": bson.M{"metrics": nil}})
+	assert.NoError(t, err)
+	assertSuccess(t, ep.mustExecuteRequest(nil))
+	metrics = flattenMetrics(MongoGetResult(testDB, startedAt).Metrics)
+	assert.Equal(t, 1.0, metrics["evaluation.codes.recall"])
+
+	// Test missing ground truth and results
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + tm + "/evaluation?ground_truth=missing"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + time.Now().Add(2*time.Hour).Format("2006-01-02T15:04:05.000Z07:00") + "/evaluation"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	_ = MongoDeleteGroundTruth(testDB, "test_dataset_3", "evaluation")
+	MongoDeleteResult(testDB, startedAt)
+}
+
+func TestResultComparison(t *testing.T) {
+	first := Result{
+		Method:      "lda",
+		Status:      "finished",
+		StartedAt:   time.Now().Add(3 * time.Hour).Truncate(time.Millisecond),
+		DatasetName: "test_dataset_3",
+		Params:      map[string]string{"topics": "2", "alpha": "0.1"},
+		Metrics:     map[string]interface{}{"f1": 0.5, "note": "text"},
+		Codes:       []Code{{Name: "login"}, {Name: "Crash"}},
+		DocTopic:    map[string]interface{}{"0": []interface{}{0.9, 0.1}, "1": []interface{}{0.2, 0.8}, "2": []interface{}{0.3, 0.7}},
+	}
+	second := first
+	second.StartedAt = first.StartedAt.Add(time.Minute)
+	second.Params = map[string]string{"topics": "3", "alpha": "0.1", "beta": "0.01"}
+	second.Metrics = map[string]interface{}{"f1": 0.75}
+	second.Codes = []Code{{Name: "crash"}, {Name: "battery"}}
+	second.DocTopic = map[string]interface{}{"0": "b", "1": "a", "2": "a"}
+	_ = MongoInsertResult(testDB, first)
+	_ = MongoInsertResult(testDB, second)
+
+	// Test comparing two results
+	ep := endpoint{"POST", "/hitec/repository/concepts/detection/result/comparison"}
+	response := ep.mustExecuteRequest(ResultComparisonRequest{Results: []time.Time{first.StartedAt, second.StartedAt}})
+	assertSuccess(t, response)
+	var comparison ResultComparison
+	assertJsonDecodes(t, response, &comparison)
+	assert.Len(t, comparison.Results, 2)
+	assert.Len(t, comparison.Params, 2)
+	assert.Equal(t, "beta", comparison.Params[0].Name)
+	assert.Nil(t, comparison.Params[0].Values[0])
+	assert.Len(t, comparison.Metrics, 1)
+	assert.Equal(t, 0.25, comparison.Metrics[0].Delta)
+	assert.Equal(t, []string{"crash"}, comparison.Codes.Shared)
+	assert.Equal(t, [][]string{{"login"}, {"battery"}}, comparison.Codes.Unique)
+	assert.Len(t, comparison.Pairs, 1)
+	assert.InDelta(t, 1.0/3, comparison.Pairs[0].Jaccard, 1e-9)
+	assert.Equal(t, 1.0, comparison.Pairs[0].TopicAlignment.NMI)
+	assert.Equal(t, []TopicMatch{{Topic: "0", Match: "b", Overlap: 1}, {Topic: "1", Match: "a", Overlap: 1}}, comparison.Pairs[0].TopicAlignment.Matches)
+
+	// Test invalid comparisons
+	assertFailure(t, ep.mustExecuteRequest(ResultComparisonRequest{Results: []time.Time{first.StartedAt}}))
+	assertFailure(t, ep.mustExecuteRequest(ResultComparisonRequest{Results: []time.Time{first.StartedAt, first.StartedAt}}))
+	assertFailure(t, ep.mustExecuteRequest(ResultComparisonRequest{Results: []time.Time{first.StartedAt, time.Now().Add(5 * time.Hour)}}))
+	assertFailure(t, ep.mustExecuteRequest(invalidPayloadString))
+
+	MongoDeleteResult(testDB, first.StartedAt)
+	MongoDeleteResult(testDB, second.StartedAt)
+}
+
+func TestResultLifecycle(t *testing.T) {
+	startedAt := time.Now().Add(6 * time.Hour).Truncate(time.Millisecond)
+	tm := startedAt.Format("2006-01-02T15:04:05.000Z07:00")
+	res := Result{
+		Method:      "lda",
+		Status:      resultScheduled,
+		StartedAt:   startedAt,
+		DatasetName: "test_dataset_3",
+	}
+
+	// Test posting a result records its status
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/detection/result/"}
+	assertSuccess(t, ep.mustExecuteRequest(res))
+	res.Status = "done"
+	assertFailure(t, ep.mustExecuteRequest(res))
+	res.Status = resultFinished
+	assertFailure(t, ep.mustExecuteRequest(res))
+	res.Status = resultRunning
+	assertSuccess(t, ep.mustExecuteRequest(res))
+	stored := MongoGetResult(testDB, startedAt)
+	assert.Equal(t, resultRunning, stored.Status)
+	assert.Len(t, stored.StatusHistory, 2)
+
+	// Test renaming is only possible for final results
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/name"}
+	response := ep.mustExecuteRequest(Result{StartedAt: startedAt, Name: "running"})
+	assertFailure(t, response)
+	assert.Equal(t, "true", response.Header().Get("Deprecation"))
+
+	// Test transitions
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + tm + "/status"}
+	assertFailure(t, ep.mustExecuteRequest(ResultStatusChange{Status: resultScheduled}))
+	assertFailure(t, ep.mustExecuteRequest(ResultStatusChange{Status: resultRunning}))
+	response = ep.mustExecuteRequest(ResultStatusChange{Status: resultCancelled, Reason: "wrong params"})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &stored)
+	assert.Equal(t, resultCancelled, stored.Status)
+	assert.Len(t, stored.StatusHistory, 3)
+	assert.Equal(t, "wrong params", stored.StatusHistory[2].Reason)
+	assertFailure(t, ep.mustExecuteRequest(ResultStatusChange{Status: resultFinished}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + time.Now().Add(7*time.Hour).Format("2006-01-02T15:04:05.000Z07:00") + "/status"}
+	assertFailure(t, ep.mustExecuteRequest(ResultStatusChange{Status: resultRunning}))
+
+	// Test results running past the timeout are failed
+	stuck := Result{Method: "lda", Status: resultRunning, StartedAt: startedAt.Add(time.Minute), DatasetName: "test_dataset_3"}
+	_ = MongoInsertResult(testDB, stuck)
+	failed, err := MongoFailStuckResults(testDB, time.Hour, startedAt.Add(30*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failed)
+	failed, err = MongoFailStuckResults(testDB, time.Hour, startedAt.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failed)
+	stored = MongoGetResult(testDB, stuck.StartedAt)
+	assert.Equal(t, resultFailed, stored.Status)
+	assert.Len(t, stored.StatusHistory, 1)
+
+	// Test a method can not revert a transition made after it read the result
+	running := stuck
+	running.StatusHistory = []ResultTransition{}
+	assert.Equal(t, mgo.ErrNotFound, MongoReplaceResult(testDB, running, Result{Status: resultRunning}))
+
+	// Test a result finishing after the timeout is still stored and keeps the name given by researchers
+	ep = endpoint{"PATCH", "/hitec/repository/concepts/store/detection/result/" + stuck.StartedAt.Format("2006-01-02T15:04:05.000Z07:00")}
+	assertSuccess(t, ep.mustExecuteRequest(map[string]interface{}{"name": "stuck run", "tags": []string{"slow"}}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/"}
+	stuck.Status = resultFinished
+	stuck.Topics = map[string]interface{}{"0": "login"}
+	assertSuccess(t, ep.mustExecuteRequest(stuck))
+	stored = MongoGetResult(testDB, stuck.StartedAt)
+	assert.Equal(t, resultFinished, stored.Status)
+	assert.Len(t, stored.StatusHistory, 2)
+	assert.Equal(t, "stuck run", stored.Name)
+	assert.Equal(t, []string{"slow"}, stored.Tags)
+
+	// Test results with legacy statuses are migrated and can be posted again
+	legacy := Result{Method: "lda", Status: "started", StartedAt: startedAt.Add(2 * time.Minute), DatasetName: "test_dataset_3"}
+	_ = MongoInsertResult(testDB, legacy)
+	_ = MongoInsertResult(testDB, Result{Method: "lda", StartedAt: startedAt.Add(3 * time.Minute), DatasetName: "test_dataset_3"})
+	MongoMigrateResultStatuses(testDB)
+	assert.Equal(t, resultRunning, MongoGetResult(testDB, legacy.StartedAt).Status)
+	stored = MongoGetResult(testDB, startedAt.Add(3*time.Minute))
+	assert.Equal(t, resultFailed, stored.Status)
+	assert.Equal(t, `migrated from status ""`, stored.StatusHistory[0].Reason)
+	legacy.Status = resultFinished
+	assertSuccess(t, ep.mustExecuteRequest(legacy))
+
+	MongoDeleteResult(testDB, startedAt)
+	MongoDeleteResult(testDB, stuck.StartedAt)
+	MongoDeleteResult(testDB, legacy.StartedAt)
+	MongoDeleteResult(testDB, startedAt.Add(3*time.Minute))
+}
+
+func TestPatchResult(t *testing.T) {
+	startedAt := time.Now().Add(8 * time.Hour).Truncate(time.Millisecond)
+	tm := startedAt.Format("2006-01-02T15:04:05.000Z07:00")
+	res := Result{Method: "lda", Status: resultRunning, StartedAt: startedAt, DatasetName: "test_dataset_3", Name: "run"}
+	other := Result{Method: "lda", Status: resultFinished, StartedAt: startedAt.Add(time.Minute), DatasetName: "test_dataset_3"}
+	_ = MongoInsertResult(testDB, res)
+	_ = MongoInsertResult(testDB, other)
+
+	// Test updating tags, notes and the pinned flag
+	ep := endpoint{"PATCH", "/hitec/repository/concepts/store/detection/result/" + tm}
+	tags := []string{" paper ", "k=10", "paper"}
+	notes := "used in table 2"
+	pinned := true
+	response := ep.mustExecuteRequest(ResultPatch{Tags: &tags, Notes: &notes, Pinned: &pinned})
+	assertSuccess(t, response)
+	var stored Result
+	assertJsonDecodes(t, response, &stored)
+	assert.Equal(t, []string{"paper", "k=10"}, stored.Tags)
+	assert.Equal(t, notes, stored.Notes)
+	assert.True(t, stored.Pinned)
+	assert.Equal(t, "run", stored.Name)
+
+	// Test invalid updates
+	name := "final name"
+	assertFailure(t, ep.mustExecuteRequest(ResultPatch{Name: &name}))
+	invalid := []string{"#paper", ""}
+	response = ep.mustExecuteRequest(ResultPatch{Tags: &invalid})
+	assertFailure(t, response)
+	var validation ValidationResponse
+	assertJsonDecodes(t, response, &validation)
+	assert.Len(t, validation.Errors, 2)
+	assertFailure(t, ep.mustExecuteRequest(invalidPayloadString))
+	ep = endpoint{"PATCH", "/hitec/repository/concepts/store/detection/result/" + startedAt.Add(time.Hour).Format("2006-01-02T15:04:05.000Z07:00")}
+	assertFailure(t, ep.mustExecuteRequest(ResultPatch{Notes: &notes}))
+
+	// Test the method updating the result keeps the tags
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/"}
+	res.Status = resultFinished
+	assertSuccess(t, ep.mustExecuteRequest(res))
+	assert.Equal(t, []string{"paper", "k=10"}, MongoGetResult(testDB, startedAt).Tags)
+	ep = endpoint{"PATCH", "/hitec/repository/concepts/store/detection/result/" + tm}
+	assertSuccess(t, ep.mustExecuteRequest(ResultPatch{Name: &name}))
+
+	// Test filtering by tags
+	ep = endpoint{"GET", "/hitec/repository/concepts/detection/result/all?tag=paper&tag=k%3D10"}
+	var results []Result
+	assertJsonDecodes(t, ep.mustExecuteRequest(nil), &results)
+	assert.Len(t, results, 1)
+	assert.Equal(t, name, results[0].Name)
+	ep = endpoint{"GET", "/hitec/repository/concepts/detection/result/all?tag=paper&tag=other"}
+	assertJsonDecodes(t, ep.mustExecuteRequest(nil), &results)
+	assert.Len(t, results, 0)
+	ep = endpoint{"GET", "/hitec/repository/concepts/detection/result/all?pinned=true"}
+	assertJsonDecodes(t, ep.mustExecuteRequest(nil), &results)
+	assert.Len(t, results, 1)
+
+	MongoDeleteResult(testDB, startedAt)
+	MongoDeleteResult(testDB, other.StartedAt)
+}
+
+func TestResultAnnotationDraft(t *testing.T) {
+	startedAt := time.Now().Add(10 * time.Hour).Truncate(time.Millisecond)
+	tm := startedAt.Format("2006-01-02T15:04:05.000Z07:00")
+	res := Result{
+		Method:      "concepts",
+		Status:      resultFinished,
+		StartedAt:   startedAt,
+		DatasetName: "test_dataset_3",
+		Codes:       []Code{{Name: "Text", Tore: "Task"}, {Name: "text 2", Tore: "Task"}, {Name: "missing"}},
+	}
+	_ = MongoInsertResult(testDB, res)
+
+	// Test drafting an annotation tokenizes the dataset
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + tm + "/annotation"}
+	response := ep.mustExecuteRequest(AnnotationDraftRequest{Name: "test_annotation_draft"})
+	assertSuccess(t, response)
+	var draft AnnotationDraft
+	assertJsonDecodes(t, response, &draft)
+	assert.True(t, draft.Tokenized)
+	assert.Equal(t, 3, draft.Suggested)
+	assert.Equal(t, []string{"missing"}, draft.Unmapped)
+
+	annotation, exists := MongoFindAnnotation(testDB, "test_annotation_draft")
+	assert.True(t, exists)
+	assert.Len(t, annotation.Tokens, 6)
+	assert.Len(t, annotation.Docs, 3)
+	assert.Equal(t, startedAt.Unix(), annotation.SourceResult.Unix())
+	for _, code := range annotation.Codes {
+		assert.True(t, code.Suggested)
+	}
+	assert.Equal(t, "text 2", annotation.Codes[0].Name)
+	assert.Len(t, annotation.Codes[0].Tokens, 2)
+	assert.Equal(t, 1, annotation.Tokens[2].NumToreCodes)
+
+	// Test a second draft shares the tokens
+	response = ep.mustExecuteRequest(AnnotationDraftRequest{Name: "test_annotation_draft_2"})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &draft)
+	assert.False(t, draft.Tokenized)
+
+	// Test invalid drafts
+	assertFailure(t, ep.mustExecuteRequest(AnnotationDraftRequest{Name: "test_annotation_draft"}))
+	assertFailure(t, ep.mustExecuteRequest(AnnotationDraftRequest{}))
+	assertFailure(t, ep.mustExecuteRequest(AnnotationDraftRequest{Name: "test_annotation_draft_3", Scheme: "missing"}))
+	res.Codes[0].Tore = "Goal"
+	_ = MongoInsertResult(testDB, res)
+	assertFailure(t, ep.mustExecuteRequest(AnnotationDraftRequest{Name: "test_annotation_draft_3"}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/detection/result/" + startedAt.Add(time.Hour).Format("2006-01-02T15:04:05.000Z07:00") + "/annotation"}
+	assertFailure(t, ep.mustExecuteRequest(AnnotationDraftRequest{Name: "test_annotation_draft_3"}))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_draft")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_draft_2")
+	MongoDeleteResult(testDB, startedAt)
+}
+
+func TestInitializeAnnotation(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/annotation/initialize/"}
+
+	// Test initializing tokenizes the documents
+	response := ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init", Dataset: "test_dataset_3", SentenceTokenization: true})
+	assertSuccess(t, response)
+	var annotation Annotation
+	assertJsonDecodes(t, response, &annotation)
+	assert.Len(t, annotation.Tokens, 6)
+	assert.Equal(t, Token{Index: intPtr(1), Name: "1", Lemma: "1", Pos: "NUM"}, annotation.Tokens[1])
+	assert.Equal(t, "text", annotation.Tokens[2].Lemma)
+	assert.Equal(t, []DocWrapper{
+		{Name: "0", BeginIndex: intPtr(0), EndIndex: intPtr(2), TextHash: documentTextHash("Text 1")},
+		{Name: "1", BeginIndex: intPtr(2), EndIndex: intPtr(4), TextHash: documentTextHash("Text 2")},
+		{Name: "2", BeginIndex: intPtr(4), EndIndex: intPtr(6), TextHash: documentTextHash("Text 3")},
+	}, annotation.Docs)
+	assert.Len(t, annotation.Sentences, 3)
+	assert.True(t, annotation.SentenceTokenizationEnabledForAnnotation)
+	stored, exists := MongoFindAnnotation(testDB, "test_annotation_init")
+	assert.True(t, exists)
+	assert.Len(t, stored.Tokens, 6)
+
+	// Test annotations of a dataset share their tokens
+	stored.Tokens[0].Lemma = "shared"
+	_ = MongoInsertAnnotation(testDB, stored)
+	response = ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_2", Dataset: "test_dataset_3"})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &annotation)
+	assert.Equal(t, "shared", annotation.Tokens[0].Lemma)
+	assert.Empty(t, annotation.Sentences)
+
+	// Test documents ingested after the tokens were shared are tokenized and appended
+	_ = MongoInsertDataset(testDB, Dataset{Name: "test_dataset_ingested", Documents: []Document{{Id: "0", Text: "Text 1"}}})
+	response = ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_4", Dataset: "test_dataset_ingested"})
+	assertSuccess(t, response)
+	_, _ = MongoIngestDocuments(testDB, "test_dataset_ingested", DatasetIngest{Documents: []Document{{Id: "1", Text: "Quoted and focused"}}})
+	response = ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_5", Dataset: "test_dataset_ingested"})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &annotation)
+	assert.Equal(t, []DocWrapper{
+		{Name: "0", BeginIndex: intPtr(0), EndIndex: intPtr(2), TextHash: documentTextHash("Text 1")},
+		{Name: "1", BeginIndex: intPtr(2), EndIndex: intPtr(5), TextHash: documentTextHash("Quoted and focused")},
+	}, annotation.Docs)
+	assert.Equal(t, Token{Index: intPtr(4), Name: "focused", Lemma: "focus", Pos: "VERB"}, annotation.Tokens[4])
+
+	// Test datasets without document ids are tokenized again once their documents are replaced
+	_ = MongoInsertDataset(testDB, Dataset{Name: "test_dataset_reposted", Documents: []Document{{Text: "Old text"}}})
+	response = ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_6", Dataset: "test_dataset_reposted"})
+	assertSuccess(t, response)
+	_ = MongoInsertDataset(testDB, Dataset{Name: "test_dataset_reposted", Documents: []Document{{Text: "New text"}}})
+	response = ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_7", Dataset: "test_dataset_reposted"})
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &annotation)
+	assert.Equal(t, "New", annotation.Tokens[0].Name)
+
+	// Test the lemmas of the english tagger
+	for word, lemma := range map[string]string{"quoted": "quote", "quoting": "quote", "focused": "focus", "focussed": "focus", "required": "require", "queued": "queue", "saved": "save", "stopped": "stop", "crashed": "crash"} {
+		assert.Equal(t, lemma, englishLemma(word, "VERB"), word)
+	}
+
+	// Test invalid requests
+	assertFailure(t, ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init", Dataset: "test_dataset_3"}))
+	assertFailure(t, ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_3", Dataset: "test_dataset_99"}))
+	assertFailure(t, ep.mustExecuteRequest(AnnotationInitRequest{Name: "test_annotation_init_3", Dataset: "test_dataset_3", Language: "xx"}))
+	assertFailure(t, ep.mustExecuteRequest(invalidPayloadString))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_init")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_init_2")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_init_4")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_init_5")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_init_6")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_init_7")
+	MongoDeleteDataset(testDB, "test_dataset_ingested")
+	MongoDeleteDataset(testDB, "test_dataset_reposted")
+}
+
+func TestAnnotationStructure(t *testing.T) {
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	annotation := testAnnotation("test_annotation_structure", "test_dataset_2")
+	annotation.Codes[2].Tokens = []*int{intPtr(8), intPtr(10)}
+	annotation.Codes[1].RelationshipMemberships = []*int{intPtr(5)}
+	annotation.TORERelationships = append(annotation.TORERelationships, TORERelationship{Index: intPtr(1), TOREEntity: intPtr(7), RelationshipName: "uses"})
+	annotation.Tokens[2].NumToreCodes = 0
+
+	// Test every problem is reported
+	response := ep.mustExecuteRequest(annotation)
+	assertFailure(t, response)
+	var validation ValidationResponse
+	assertJsonDecodes(t, response, &validation)
+	var fields []string
+	for _, err := range validation.Errors {
+		fields = append(fields, err.Field)
+	}
+	assert.Contains(t, fields, "codes[2].tokens[1]")
+	assert.Contains(t, fields, "codes[1].relationship_memberships[0]")
+	assert.Contains(t, fields, "tore_relationships[1].TOREEntity")
+	assert.Contains(t, fields, "tokens[2].num_tore_codes")
+	assert.Contains(t, fields, "tokens[9].num_name_codes")
+
+	// Test repairing drops the dangling references and recomputes the counters
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/?repair=true"}
+	response = ep.mustExecuteRequest(annotation)
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &validation)
+	assert.True(t, validation.Status)
+	assert.NotEmpty(t, validation.Errors)
+	stored, exists := MongoFindAnnotation(testDB, "test_annotation_structure")
+	assert.True(t, exists)
+	assert.Len(t, stored.Codes, 3)
+	assert.Len(t, stored.Codes[2].Tokens, 1)
+	assert.Len(t, stored.TORERelationships, 1)
+	assert.Empty(t, stored.Codes[1].RelationshipMemberships)
+	assert.Equal(t, 1, stored.Tokens[2].NumToreCodes)
+	assert.Equal(t, 0, stored.Tokens[9].NumNameCodes)
+	assert.Empty(t, validateAnnotationStructure(stored))
+
+	// Test ranges that can not be repaired
+	annotation.Docs[1].EndIndex = intPtr(11)
+	assertFailure(t, ep.mustExecuteRequest(annotation))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_structure")
+}
+
+func TestCloneAnnotation(t *testing.T) {
+	_ = MongoInsertAnnotation(testDB, testAnnotation("test_annotation_clone", "test_dataset_2"))
+	base := "/hitec/repository/concepts/store/annotation/name/test_annotation_clone/clone"
+
+	// Test keeping the codes of selected categories drops the relationships of removed codes
+	ep := endpoint{"POST", base}
+	response := ep.mustExecuteRequest(AnnotationCloneRequest{Name: "test_annotation_clone_domain", Tores: []string{"Domain Data"}})
+	assertSuccess(t, response)
+	var clone Annotation
+	assertJsonDecodes(t, response, &clone)
+	assert.Equal(t, "test_annotation_clone", clone.ClonedFrom)
+	assert.Len(t, clone.Tokens, 10)
+	assert.Len(t, clone.Codes, 1)
+	assert.Empty(t, clone.TORERelationships)
+	assert.Equal(t, 0, clone.Tokens[2].NumToreCodes)
+	assert.Equal(t, 1, clone.Tokens[7].NumToreCodes)
+
+	// Test stripping codes keeps the documents and tokens
+	response = ep.mustExecuteRequest(AnnotationCloneRequest{Name: "test_annotation_clone_empty", StripCodes: true})
+	assertSuccess(t, response)
+	stored, exists := MongoFindAnnotation(testDB, "test_annotation_clone_empty")
+	assert.True(t, exists)
+	assert.Len(t, stored.Docs, 2)
+	assert.Len(t, stored.Tokens, 10)
+	assert.Empty(t, stored.Codes)
+	assert.Equal(t, 0, stored.Tokens[8].NumNameCodes)
+
+	// Test validation
+	response = ep.mustExecuteRequest(AnnotationCloneRequest{Name: "test_annotation_clone_empty", Tores: []string{"Unknown"}})
+	assertFailure(t, response)
+	var validation ValidationResponse
+	assertJsonDecodes(t, response, &validation)
+	assert.Len(t, validation.Errors, 2)
+	assertFailure(t, ep.mustExecuteRequest(AnnotationCloneRequest{Name: " "}))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/name/unknown/clone"}
+	assertFailure(t, ep.mustExecuteRequest(AnnotationCloneRequest{Name: "test_annotation_clone_unknown"}))
+	_ = MongoInsertAnnotation(testDB, testAnnotation("test_annotation_clone_orphan", "unknown_dataset"))
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/name/test_annotation_clone_orphan/clone"}
+	assertFailure(t, ep.mustExecuteRequest(AnnotationCloneRequest{Name: "test_annotation_clone_orphan_2"}))
+
+	for _, name := range []string{"test_annotation_clone", "test_annotation_clone_domain", "test_annotation_clone_empty", "test_annotation_clone_orphan"} {
+		_ = MongoDeleteAnnotation(testDB, name)
+	}
+}
+
+func TestAnnotationAssignment(t *testing.T) {
+	uncoded := testAnnotation("test_annotation_assignment_1", "test_dataset_2")
+	uncoded.Codes, uncoded.TORERelationships = []Code{}, []TORERelationship{}
+	for i := range uncoded.Tokens {
+		uncoded.Tokens[i].NumNameCodes, uncoded.Tokens[i].NumToreCodes = 0, 0
+	}
+	_ = MongoInsertAnnotation(testDB, uncoded)
+	_ = MongoInsertAnnotation(testDB, testAnnotation("test_annotation_assignment_2", "test_dataset_2"))
+
+	// Test assigning marks the annotations as not started
+	ep := endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_1/assignment"}
+	response := ep.mustExecuteRequest(map[string]string{"assignee": " student_a "})
+	assertSuccess(t, response)
+	var progress AnnotationProgress
+	assertJsonDecodes(t, response, &progress)
+	assert.Equal(t, "student_a", progress.Assignee)
+	assert.Equal(t, annotationNotStarted, progress.Status)
+	assert.Equal(t, 0.0, progress.Progress)
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_2/assignment"}
+	assertSuccess(t, ep.mustExecuteRequest(map[string]string{"assignee": "student_a", "status": annotationSubmitted}))
+	assertFailure(t, ep.mustExecuteRequest(map[string]string{"status": "unknown"}))
+
+	// Test saving codes starts the annotation
+	uncoded.Codes = []Code{{Index: intPtr(0), Name: "login", Tore: "Task", Tokens: []*int{intPtr(8)}, RelationshipMemberships: []*int{}}}
+	uncoded.Tokens[8].NumNameCodes, uncoded.Tokens[8].NumToreCodes = 1, 1
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	assertSuccess(t, ep.mustExecuteRequest(uncoded))
+	stored, _ := MongoFindAnnotation(testDB, "test_annotation_assignment_1")
+	assert.Equal(t, annotationInProgress, stored.Status)
+	assert.Equal(t, "student_a", stored.Assignee)
+
+	// Test saving ignores the assignment of the body
+	uncoded.Assignee, uncoded.Status, uncoded.DoneDocs = "student_b", annotationReviewed, []string{"0"}
+	assertSuccess(t, ep.mustExecuteRequest(uncoded))
+	stored, _ = MongoFindAnnotation(testDB, "test_annotation_assignment_1")
+	assert.Equal(t, annotationInProgress, stored.Status)
+	assert.Equal(t, "student_a", stored.Assignee)
+	assert.Empty(t, stored.DoneDocs)
+
+	// Test documents marked as done count as progress
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_1/done/0"}
+	response = ep.mustExecuteRequest(nil)
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &progress)
+	assert.Equal(t, 1, progress.NumDocsCoded)
+	assert.Equal(t, 1, progress.NumDocsDone)
+	assert.Equal(t, 1.0, progress.Progress)
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_1/done/0"}
+	response = ep.mustExecuteRequest(nil)
+	assertJsonDecodes(t, response, &progress)
+	assert.Equal(t, 0.5, progress.Progress)
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_1/done/9"}
+	assertFailure(t, ep.mustExecuteRequest(nil))
+
+	// Test the progress overview and the work queue
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/progress?assignee=student_a"}
+	response = ep.mustExecuteRequest(nil)
+	var overview []AnnotationProgress
+	assertJsonDecodes(t, response, &overview)
+	assert.Len(t, overview, 2)
+	assert.Equal(t, 1.0, overview[1].Progress)
+	ep = endpoint{"GET", "/hitec/repository/concepts/annotation/assignee/student_a/queue"}
+	response = ep.mustExecuteRequest(nil)
+	var queue []AnnotationProgress
+	assertJsonDecodes(t, response, &queue)
+	assert.Len(t, queue, 1)
+	assert.Equal(t, "test_annotation_assignment_1", queue[0].Name)
+
+	// Test only submitted annotations can be reviewed
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_1/assignment"}
+	assertFailure(t, ep.mustExecuteRequest(map[string]string{"status": annotationReviewed}))
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_2/assignment"}
+	assertSuccess(t, ep.mustExecuteRequest(map[string]string{"status": annotationReviewed}))
+
+	// Test started annotations do not become not started again and reviewed ones are only reopened in progress
+	assertFailure(t, ep.mustExecuteRequest(map[string]string{"status": annotationNotStarted}))
+	assertFailure(t, ep.mustExecuteRequest(map[string]string{"status": annotationSubmitted}))
+	assertSuccess(t, ep.mustExecuteRequest(map[string]string{"status": annotationInProgress}))
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_assignment_1/assignment"}
+	assertFailure(t, ep.mustExecuteRequest(map[string]string{"status": annotationNotStarted}))
+
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_assignment_1")
+	_ = MongoDeleteAnnotation(testDB, "test_annotation_assignment_2")
+}
+
+func signTestJWT(secret string, claims map[string]interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthorization(t *testing.T) {
+	authn = authenticator{jwtSecret: []byte("test_secret"), apiKeys: map[string]Principal{hashAPIKey("crawler_key"): {Name: "crawler", Role: roleCrawlerService}}}
+	defer func() { authn = authenticator{disabled: true} }()
+	bearer := func(name string, role string) string {
+		return "Bearer " + signTestJWT("test_secret", map[string]interface{}{"sub": name, "role": role, "exp": time.Now().Add(time.Hour).Unix()})
+	}
+	MongoMigrateProjects(testDB, []string{"researcher_a", "student_a", "student_b"})
+	project, _ := MongoGetProject(testDB, defaultProject)
+	assert.Equal(t, []string{"researcher_a", "student_a", "student_b"}, project.Members)
+
+	// Test requests need valid credentials
+	ep := endpoint{"GET", "/hitec/repository/concepts/dataset/all"}
+	assert.Equal(t, http.StatusUnauthorized, ep.mustExecuteRequest(nil).Code)
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, bearer("researcher_a", roleResearcher)))
+	assert.Equal(t, http.StatusUnauthorized, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, "Bearer "+signTestJWT("other_secret", map[string]interface{}{"sub": "a", "role": roleAdmin, "exp": time.Now().Add(time.Hour).Unix()})).Code)
+	assert.Equal(t, http.StatusUnauthorized, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, "Bearer "+signTestJWT("test_secret", map[string]interface{}{"sub": "a", "role": roleAdmin, "exp": time.Now().Add(-time.Minute).Unix()})).Code)
+	assert.Equal(t, http.StatusUnauthorized, ep.mustExecuteRequestWithHeaders(nil, apiKeyKey, "unknown_key").Code)
+
+	// Test roles are enforced per route
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/dataset/name/unknown_dataset"}
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, bearer("researcher_a", roleResearcher)).Code)
+	ep = endpoint{"GET", "/hitec/repository/concepts/crawler/jobs"}
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(nil, apiKeyKey, "crawler_key"))
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, bearer("student_a", roleAnnotator)).Code)
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/crawler/jobs"}
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(CrawlerJob{}, authorizationKey, bearer("researcher_a", roleResearcher)).Code)
+	assert.Equal(t, http.StatusBadRequest, ep.mustExecuteRequestWithHeaders(CrawlerJob{}, apiKeyKey, "crawler_key").Code)
+
+	// Test annotators only modify the annotations assigned to them
+	annotation := testAnnotation("test_annotation_authorization", "test_dataset_2")
+	annotation.Assignee, annotation.Status = "student_a", annotationInProgress
+	_ = MongoInsertAnnotation(testDB, annotation)
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(annotation, authorizationKey, bearer("student_b", roleAnnotator)).Code)
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(annotation, authorizationKey, bearer("student_a", roleAnnotator)))
+	ep = endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_authorization/assignment"}
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(map[string]string{"assignee": "student_b"}, authorizationKey, bearer("student_a", roleAnnotator)).Code)
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(map[string]string{"status": annotationSubmitted}, authorizationKey, bearer("student_a", roleAnnotator)))
+
+	// Test annotators can not change their annotations once they are reviewed
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(map[string]string{"status": annotationReviewed}, authorizationKey, bearer("researcher_a", roleResearcher)))
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(map[string]string{"status": annotationInProgress}, authorizationKey, bearer("student_a", roleAnnotator)).Code)
+	saveEp := endpoint{"POST", "/hitec/repository/concepts/store/annotation/"}
+	assert.Equal(t, http.StatusForbidden, saveEp.mustExecuteRequestWithHeaders(annotation, authorizationKey, bearer("student_a", roleAnnotator)).Code)
+	doneEp := endpoint{"PUT", "/hitec/repository/concepts/store/annotation/name/test_annotation_authorization/done/0"}
+	assert.Equal(t, http.StatusForbidden, doneEp.mustExecuteRequestWithHeaders(nil, authorizationKey, bearer("student_a", roleAnnotator)).Code)
+	ep = endpoint{"DELETE", "/hitec/repository/concepts/annotation/name/test_annotation_authorization"}
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, bearer("student_a", roleAnnotator)).Code)
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, bearer("researcher_a", roleResearcher)))
+
+	// Test cross-origin requests are only allowed from the configured origins
+	assert.False(t, isAllowedOrigin(nil)("http://localhost:3000"))
+	assert.True(t, isAllowedOrigin([]string{"http://localhost:3000"})("http://localhost:3000"))
+	assert.False(t, isAllowedOrigin([]string{"http://localhost:3000"})("https://example.org"))
+	assert.True(t, isAllowedOrigin([]string{"*"})("https://example.org"))
+}
+
+func TestProjects(t *testing.T) {
+	authn = authenticator{jwtSecret: []byte("test_secret")}
+	defer func() { authn = authenticator{disabled: true} }()
+	defer func() { _ = mongoClient.DB(projectDatabase("test_project")).DropDatabase() }()
+	bearer := func(name string, role string) string {
+		return "Bearer " + signTestJWT("test_secret", map[string]interface{}{"sub": name, "role": role, "exp": time.Now().Add(time.Hour).Unix()})
+	}
+	admin, member, outsider := bearer("admin_a", roleAdmin), bearer("researcher_b", roleResearcher), bearer("researcher_c", roleResearcher)
+
+	// Test only admins create projects
+	ep := endpoint{"POST", "/hitec/repository/concepts/store/project/"}
+	project := Project{Name: "test_project", Members: []string{"researcher_b", " researcher_b "}}
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(project, authorizationKey, member).Code)
+	response := ep.mustExecuteRequestWithHeaders(project, authorizationKey, admin)
+	assertSuccess(t, response)
+	assertJsonDecodes(t, response, &project)
+	assert.Equal(t, []string{"researcher_b"}, project.Members)
+	assert.Equal(t, http.StatusConflict, ep.mustExecuteRequestWithHeaders(project, authorizationKey, admin).Code)
+	assertFailure(t, ep.mustExecuteRequestWithHeaders(Project{Name: "Invalid Name"}, authorizationKey, admin))
+
+	ep = endpoint{"GET", "/hitec/repository/concepts/project/all"}
+	var projects []Project
+	assertJsonDecodes(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, member), &projects)
+	assert.Len(t, projects, 1)
+	assertJsonDecodes(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, admin), &projects)
+	assert.True(t, len(projects) >= 2)
+
+	// Test data of a project is only visible within the project
+	ep = endpoint{"POST", "/hitec/repository/concepts/store/dataset/"}
+	dataset := Dataset{UploadedAt: time.Now(), Name: "test_project_dataset", Size: 1, Documents: []Document{{Id: "0", Text: "Text"}}}
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(dataset, authorizationKey, member, projectKey, "test_project"))
+	ep = endpoint{"GET", "/hitec/repository/concepts/dataset/name/test_project_dataset"}
+	var stored Dataset
+	assertJsonDecodes(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, member, projectKey, "test_project"), &stored)
+	assert.Equal(t, "test_project_dataset", stored.Name)
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, member).Code)
+	stored = Dataset{}
+	assertJsonDecodes(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, admin), &stored)
+	assert.Empty(t, stored.Name)
+
+	// Test members are managed by admins
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, outsider, projectKey, "test_project").Code)
+	assert.Equal(t, http.StatusNotFound, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, admin, projectKey, "unknown_project").Code)
+	memberEp := endpoint{"PUT", "/hitec/repository/concepts/store/project/test_project/member/researcher_c"}
+	assertSuccess(t, memberEp.mustExecuteRequestWithHeaders(nil, authorizationKey, admin))
+	assertSuccess(t, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, outsider, projectKey, "test_project"))
+	memberEp = endpoint{"DELETE", "/hitec/repository/concepts/store/project/test_project/member/researcher_c"}
+	assertSuccess(t, memberEp.mustExecuteRequestWithHeaders(nil, authorizationKey, admin))
+	assert.Equal(t, http.StatusForbidden, ep.mustExecuteRequestWithHeaders(nil, authorizationKey, outsider, projectKey, "test_project").Code)
+	memberEp = endpoint{"PUT", "/hitec/repository/concepts/store/project/unknown_project/member/researcher_c"}
+	assert.Equal(t, http.StatusNotFound, memberEp.mustExecuteRequestWithHeaders(nil, authorizationKey, admin).Code)
+}
+
 func TestQueries(t *testing.T) {
 	mongoClient.Close()
 	assert.Panics(t, func() {
-		MongoCreateCollectionIndexes(mongoClient)
+		MongoCreateCollectionIndexes(testDB)
 	})
 }
 
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// suggestCodes adds the codes of a result to the annotation as machine-suggested codes. A code is placed on every
+// occurrence of its name in the documents, matching the names or lemmas of the tokens. Longer names are placed first
+// and tokens are only covered by a single suggestion. The names of codes that do not occur are returned.
+func suggestCodes(annotation *Annotation, codes []Code) []string {
+	type suggestion struct {
+		code  Code
+		words []string
+	}
+	var suggestions []suggestion
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		key := normalizeTerm(code.Name) + "\x00" + code.Tore
+		words := tokenizeText(strings.ToLower(code.Name))
+		if len(words) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, suggestion{code: code, words: words})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return len(suggestions[i].words) > len(suggestions[j].words) })
+
+	covered := make([]bool, len(annotation.Tokens))
+	for _, code := range annotation.Codes {
+		for _, index := range validTokenIndices(code.Tokens, len(annotation.Tokens)) {
+			covered[index] = true
+		}
+	}
+	nextIndex := 0
+	for _, code := range annotation.Codes {
+		if code.Index != nil && *code.Index >= nextIndex {
+			nextIndex = *code.Index + 1
+		}
+	}
+
+	var unmapped []string
+	for _, s := range suggestions {
+		placed := false
+		for _, doc := range documentRanges(annotation.Docs, len(annotation.Tokens)) {
+			for begin := doc[0]; begin+len(s.words) <= doc[1]; begin++ {
+				if !matchesWords(annotation.Tokens[begin:begin+len(s.words)], covered[begin:begin+len(s.words)], s.words) {
+					continue
+				}
+				index := nextIndex
+				nextIndex++
+				code := Code{Name: s.code.Name, Tore: s.code.Tore, Index: &index, RelationshipMemberships: []*int{}, Suggested: true}
+				for i := begin; i < begin+len(s.words); i++ {
+					token := i
+					code.Tokens = append(code.Tokens, &token)
+					covered[i] = true
+					if code.Name != "" {
+						annotation.Tokens[i].NumNameCodes++
+					}
+					if code.Tore != "" {
+						annotation.Tokens[i].NumToreCodes++
+					}
+				}
+				annotation.Codes = append(annotation.Codes, code)
+				placed = true
+				begin += len(s.words) - 1
+			}
+		}
+		if !placed {
+			unmapped = append(unmapped, s.code.Name)
+		}
+	}
+	return unmapped
+}
+
+// matchesWords checks that none of the tokens is covered yet and each token matches the word by name or lemma
+func matchesWords(tokens []Token, covered []bool, words []string) bool {
+	for i, token := range tokens {
+		if covered[i] || (strings.ToLower(token.Name) != words[i] && strings.ToLower(token.Lemma) != words[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// documentRanges returns the token ranges of the documents, the whole token list if there are no documents, so
+// that codes do not span documents
+func documentRanges(docs []DocWrapper, numTokens int) [][2]int {
+	var ranges [][2]int
+	for _, doc := range docs {
+		if doc.BeginIndex == nil || doc.EndIndex == nil {
+			continue
+		}
+		begin, end := *doc.BeginIndex, *doc.EndIndex
+		if begin < 0 {
+			begin = 0
+		}
+		if end > numTokens {
+			end = numTokens
+		}
+		if begin < end {
+			ranges = append(ranges, [2]int{begin, end})
+		}
+	}
+	if len(ranges) == 0 {
+		ranges = append(ranges, [2]int{0, numTokens})
+	}
+	return ranges
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// englishTagger is a small rule based tagger for English using universal part of speech tags. Closed word classes
+// are looked up, open ones are guessed from suffixes and the previous word. It is meant as a fallback that produces
+// usable lemmas for code recommendations, not as a replacement for a statistical tagger.
+type englishTagger struct{}
+
+var englishClosedClasses = map[string]string{}
+
+func init() {
+	classes := map[string]string{
+		"DET":   "a an the this that these those some any each every no another either neither all both my your his her its our their",
+		"PRON":  "i me mine myself you yours yourself we us ours ourselves he him himself she hers herself it itself they them theirs themselves who whom whose which what something anything nothing everything someone anyone everyone nobody",
+		"ADP":   "in on at by for with about against between into through during before after above below to from up down of off over under again further than via per without within",
+		"CCONJ": "and or but nor yet so",
+		"SCONJ": "if because while although though unless since until whether when where whereas once",
+		"AUX":   "am is are was were be been being have has had having do does did can could shall should will would may might must ca wo 're 'm 've 'll 'd",
+		"PART":  "not n't 's ’s",
+		"ADV":   "very too also just only even still already always never often sometimes here there now then again really quite almost",
+		"INTJ":  "yes oh hi hello please thanks ok okay wow",
+	}
+	for pos, words := range classes {
+		for _, word := range strings.Fields(words) {
+			englishClosedClasses[word] = pos
+		}
+	}
+}
+
+// englishIrregularLemmas are lemmas that can not be derived by removing suffixes
+var englishIrregularLemmas = map[string]string{
+	"am": "be", "is": "be", "are": "be", "was": "be", "were": "be", "been": "be", "being": "be",
+	"has": "have", "had": "have", "having": "have", "does": "do", "did": "do", "done": "do",
+	"went": "go", "gone": "go", "goes": "go", "made": "make", "got": "get", "gotten": "get", "took": "take",
+	"taken": "take", "gave": "give", "given": "give", "saw": "see", "seen": "see", "came": "come", "knew": "know",
+	"known": "know", "thought": "think", "found": "find", "told": "tell", "said": "say", "paid": "pay",
+	"bought": "buy", "sent": "send", "kept": "keep", "left": "leave", "lost": "lose", "ran": "run", "wrote": "write",
+	"written": "write", "broke": "break", "broken": "break", "chose": "choose", "chosen": "choose", "began": "begin",
+	"begun": "begin", "felt": "feel", "built": "build", "could": "can", "would": "will", "should": "shall",
+	"better": "good", "best": "good", "worse": "bad", "worst": "bad",
+	"children": "child", "men": "man", "women": "woman", "people": "person", "feet": "foot", "teeth": "tooth",
+	"mice": "mouse", "data": "data", "news": "news", "series": "series",
+	"me": "i", "us": "we", "him": "he", "her": "she", "them": "they",
+	"used": "use", "using": "use", "n't": "not", "n’t": "not", "ca": "can", "wo": "will",
+	"'re": "be", "'m": "be", "'ve": "have", "'ll": "will", "'d": "would",
+}
+
+// englishSuffixes guess the part of speech of open class words by their suffix
+var englishSuffixes = []struct {
+	suffix string
+	pos    string
+}{
+	{"ly", "ADV"},
+	{"tion", "NOUN"}, {"sion", "NOUN"}, {"ment", "NOUN"}, {"ness", "NOUN"}, {"ity", "NOUN"}, {"ship", "NOUN"},
+	{"ance", "NOUN"}, {"ence", "NOUN"}, {"ism", "NOUN"}, {"ist", "NOUN"},
+	{"ful", "ADJ"}, {"ous", "ADJ"}, {"able", "ADJ"}, {"ible", "ADJ"}, {"ive", "ADJ"}, {"less", "ADJ"}, {"ish", "ADJ"},
+	{"ic", "ADJ"}, {"al", "ADJ"},
+	{"ing", "VERB"}, {"ed", "VERB"}, {"ize", "VERB"}, {"ise", "VERB"}, {"ify", "VERB"},
+}
+
+func (englishTagger) tag(words []string) ([]string, []string) {
+	pos := make([]string, len(words))
+	lemmas := make([]string, len(words))
+	for i, word := range words {
+		lower := strings.ToLower(word)
+		previous, previousWord := "", ""
+		if i > 0 {
+			previous, previousWord = pos[i-1], strings.ToLower(words[i-1])
+		}
+		pos[i] = englishPos(word, lower, previous, previousWord, i == 0)
+		lemmas[i] = englishLemma(lower, pos[i])
+	}
+	return pos, lemmas
+}
+
+func englishPos(word string, lower string, previous string, previousWord string, first bool) string {
+	if isPunctuation(word) {
+		return "PUNCT"
+	}
+	if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' && r != ',' }) < 0 {
+		return "NUM"
+	}
+	if pos, ok := englishClosedClasses[lower]; ok {
+		return pos
+	}
+	if !first && unicode.IsUpper([]rune(word)[0]) {
+		return "PROPN"
+	}
+	for _, suffix := range englishSuffixes {
+		stem := strings.TrimSuffix(lower, suffix.suffix)
+		if len(stem) > 2 && stem != lower && strings.IndexAny(stem, "aeiouy") >= 0 {
+			// participles after articles and adjectives are used as nouns or adjectives, e.g. "the loading screen"
+			if suffix.pos == "VERB" && (previous == "DET" || previous == "ADJ") {
+				return "NOUN"
+			}
+			return suffix.pos
+		}
+	}
+	if previous == "PRON" || previous == "AUX" || previous == "PART" || previousWord == "to" {
+		return "VERB"
+	}
+	return "NOUN"
+}
+
+func englishLemma(lower string, pos string) string {
+	if lemma, ok := englishIrregularLemmas[lower]; ok {
+		return lemma
+	}
+	switch pos {
+	case "NOUN", "PROPN":
+		return englishSingular(lower)
+	case "VERB", "AUX":
+		return englishVerbStem(lower)
+	}
+	return lower
+}
+
+func englishSingular(word string) string {
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+func englishVerbStem(word string) string {
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"), strings.HasSuffix(word, "ied"):
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ing") && len(word) > 5 && strings.IndexAny(word[:len(word)-3], "aeiouy") >= 0:
+		return restoreStem(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		return restoreStem(word[:len(word)-2])
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// englishStemExceptions are stems the rules of restoreStem would change wrongly
+var englishStemExceptions = map[string]string{
+	"focus": "focus", "focuss": "focus",
+}
+
+// restoreStem undoes the doubled consonant of "stopped" and the dropped e of "crashed" versus "saved"
+func restoreStem(stem string) string {
+	if restored, ok := englishStemExceptions[stem]; ok {
+		return restored
+	}
+	n := len(stem)
+	if n >= 2 && stem[n-1] == stem[n-2] && !strings.ContainsRune("aeioulsz", rune(stem[n-1])) {
+		return stem[:n-1]
+	}
+	if n >= 3 && isConsonant(stem[n-3]) && isVowel(stem[n-2]) && isConsonant(stem[n-1]) && !strings.ContainsRune("wxy", rune(stem[n-1])) && n <= 4 {
+		return stem + "e"
+	}
+	// the u of qu is a consonant, e.g. "quoted" and "required"
+	if n >= 4 && stem[n-4:n-2] == "qu" && isVowel(stem[n-2]) && isConsonant(stem[n-1]) {
+		return stem + "e"
+	}
+	if strings.HasSuffix(stem, "u") || strings.HasSuffix(stem, "v") || strings.HasSuffix(stem, "at") || strings.HasSuffix(stem, "iz") || strings.HasSuffix(stem, "us") {
+		return stem + "e"
+	}
+	return stem
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
+
+func isConsonant(c byte) bool {
+	return c >= 'a' && c <= 'z' && !isVowel(c)
+}
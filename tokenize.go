@@ -0,0 +1,152 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// defaultLanguage is the language of the tagger used if an annotation does not name one
+const defaultLanguage = "en"
+
+// wordPattern matches, in this order, known abbreviations, dotted abbreviations like "e.g.", numbers like "3.5" or
+// "1,000", words including contractions like "don't" and single punctuation characters
+var wordPattern = regexp.MustCompile(`(?i:\b(?:mr|mrs|ms|dr|prof|st|etc|vs|approx|incl|resp)\.)|(?:\pL\.){2,}|\pN+(?:[.,]\pN+)+|[\pL\pN]+(?:['’][\pL\pN]+)*|[^\s\pL\pN]`)
+
+// sentenceEndings are the tokens ending a sentence, closing quotes and brackets following them belong to the sentence
+var sentenceEndings = []string{".", "!", "?", "…"}
+
+// tokenTagger assigns parts of speech and lemmas to the words of a sentence. Taggers for other languages or backed
+// by an NLP service implement it and are registered in tokenTaggers.
+type tokenTagger interface {
+	tag(words []string) (pos []string, lemmas []string)
+}
+
+// tokenTaggers are the available taggers by language
+var tokenTaggers = map[string]tokenTagger{
+	defaultLanguage: englishTagger{},
+}
+
+// tokenizeText splits a text into word and punctuation tokens, contractions are split into the word and its clitic
+// like "do" "n't" and "it" "'s"
+func tokenizeText(text string) []string {
+	var words []string
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		i := strings.IndexAny(word, "'’")
+		if i <= 0 || strings.ContainsAny(word[i+1:], "'’") {
+			words = append(words, word)
+			continue
+		}
+		if clitic := strings.ToLower(word[i-1:]); i > 1 && (clitic == "n't" || clitic == "n’t") {
+			i--
+		}
+		words = append(words, word[:i], word[i:])
+	}
+	return words
+}
+
+// splitSentences returns the end index (exclusive) of every sentence of the words, the last sentence ends with the
+// words even without an ending
+func splitSentences(words []string) []int {
+	var ends []int
+	for i := 0; i < len(words); i++ {
+		if !containsString(sentenceEndings, words[i]) {
+			continue
+		}
+		for i+1 < len(words) && (containsString(sentenceEndings, words[i+1]) || isClosingPunctuation(words[i+1])) {
+			i++
+		}
+		ends = append(ends, i+1)
+	}
+	if len(words) > 0 && (len(ends) == 0 || ends[len(ends)-1] != len(words)) {
+		ends = append(ends, len(words))
+	}
+	return ends
+}
+
+func isClosingPunctuation(word string) bool {
+	for _, r := range word {
+		if !unicode.In(r, unicode.Pe, unicode.Pf) && r != '"' && r != '\'' {
+			return false
+		}
+	}
+	return word != ""
+}
+
+// tokenizeDocuments returns the tagged tokens of all documents in order and the token range of every document
+func tokenizeDocuments(documents []Document, tagger tokenTagger) ([]DocWrapper, []Token) {
+	docs := make([]DocWrapper, 0, len(documents))
+	tokens := []Token{}
+	for _, document := range documents {
+		begin := len(tokens)
+		words := tokenizeText(document.Text)
+		sentenceBegin := 0
+		for _, sentenceEnd := range splitSentences(words) {
+			sentence := words[sentenceBegin:sentenceEnd]
+			pos, lemmas := tagger.tag(sentence)
+			for i, word := range sentence {
+				index := len(tokens)
+				tokens = append(tokens, Token{Index: &index, Name: word, Lemma: lemmas[i], Pos: pos[i]})
+			}
+			sentenceBegin = sentenceEnd
+		}
+		end := len(tokens)
+		docs = append(docs, DocWrapper{Name: document.Id, BeginIndex: &begin, EndIndex: &end, TextHash: documentTextHash(document.Text)})
+	}
+	return docs, tokens
+}
+
+// isDocumentsPrefix returns whether the tokenized documents are the first documents of the dataset, which holds until
+// the documents of the dataset are replaced because ingests only append documents. Documents are compared by id and
+// text as ids are optional, documents tokenized without a text hash never match.
+func isDocumentsPrefix(docs []DocWrapper, documents []Document) bool {
+	if len(docs) > len(documents) {
+		return false
+	}
+	for i, doc := range docs {
+		if doc.Name != documents[i].Id || doc.TextHash == "" || doc.TextHash != documentTextHash(documents[i].Text) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendTokens appends tokenized documents to the documents and tokens of an annotation, shifting their indices
+func appendTokens(docs []DocWrapper, tokens []Token, moreDocs []DocWrapper, moreTokens []Token) ([]DocWrapper, []Token) {
+	offset := len(tokens)
+	for _, doc := range moreDocs {
+		begin, end := *doc.BeginIndex+offset, *doc.EndIndex+offset
+		docs = append(docs, DocWrapper{Name: doc.Name, BeginIndex: &begin, EndIndex: &end, TextHash: doc.TextHash})
+	}
+	for _, token := range moreTokens {
+		index := *token.Index + offset
+		token.Index = &index
+		tokens = append(tokens, token)
+	}
+	return docs, tokens
+}
+
+// tokenSentences returns the token range of every sentence, named by their document. The sentences are computed from
+// the tokens so annotations sharing tokens also share sentences.
+func tokenSentences(docs []DocWrapper, tokens []Token) []DocWrapper {
+	sentences := []DocWrapper{}
+	for _, doc := range documentRanges(docs, len(tokens)) {
+		name, _, _ := documentBounds(docs, doc[0], len(tokens))
+		words := make([]string, 0, doc[1]-doc[0])
+		for _, token := range tokens[doc[0]:doc[1]] {
+			words = append(words, token.Name)
+		}
+		begin := doc[0]
+		for _, end := range splitSentences(words) {
+			sentenceBegin, sentenceEnd := begin, doc[0]+end
+			sentences = append(sentences, DocWrapper{Name: name, BeginIndex: &sentenceBegin, EndIndex: &sentenceEnd})
+			begin = sentenceEnd
+		}
+	}
+	return sentences
+}
+
+// isPunctuation checks that the word has no letters or digits
+func isPunctuation(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0
+}